@@ -0,0 +1,470 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hacker-news-ai/config"
+	"github.com/hacker-news-ai/database"
+	"github.com/hacker-news-ai/models"
+	"github.com/hacker-news-ai/services"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// App 应用容器，按配置创建数据库操作、AI 服务、各来源及其发布目标
+// 各实例互不影响，可以在同一进程中使用不同配置创建多个
+type App struct {
+	StoryRepo      *database.StoryRepository
+	DigestRepo     *database.DigestRepository
+	SubscriberRepo *database.SubscriberRepository
+	ReportRepo     *database.RunReportRepository
+
+	// 当前使用的 pipeline，重新加载配置时整体替换
+	current atomic.Pointer[pipeline]
+
+	// 运行指标，守护进程模式下通过 /metrics 提供，单次运行时可推送到 Pushgateway
+	metrics *services.Metrics
+	// 链路追踪，每次运行创建一条链路，未配置 tracing.endpoint 时不记录
+	tracerProvider  trace.TracerProvider
+	shutdownTracing func(context.Context) error
+
+	// runMu 保证同一时间只有一次运行
+	runMu sync.Mutex
+	// 各来源上次运行时间，配置了运行间隔的来源未到间隔时跳过
+	lastRun map[string]time.Time
+}
+
+// New 连接配置中的数据库并创建应用
+func New(cfg *config.Config) (*App, error) {
+	db, err := database.OpenDB(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("初始化数据库失败: %v", err)
+	}
+	return NewWithDB(cfg, db)
+}
+
+// NewWithDB 使用已有的数据库连接创建应用，用于嵌入其他程序或测试
+func NewWithDB(cfg *config.Config, db *gorm.DB) (*App, error) {
+	tracerProvider, shutdownTracing, err := services.NewTracerProvider(cfg.Tracing)
+	if err != nil {
+		return nil, fmt.Errorf("初始化链路追踪失败: %v", err)
+	}
+	a := &App{
+		StoryRepo:       database.NewStoryRepository(db),
+		DigestRepo:      database.NewDigestRepository(db),
+		SubscriberRepo:  database.NewSubscriberRepository(db),
+		ReportRepo:      database.NewRunReportRepository(db),
+		metrics:         services.NewMetrics(),
+		tracerProvider:  tracerProvider,
+		shutdownTracing: shutdownTracing,
+		lastRun:         make(map[string]time.Time),
+	}
+	p, err := a.newPipeline(cfg, nil)
+	if err != nil {
+		return nil, err
+	}
+	a.current.Store(p)
+	return a, nil
+}
+
+// Shutdown 导出尚未发送的链路，退出前调用
+func (a *App) Shutdown(ctx context.Context) error {
+	if err := a.shutdownTracing(ctx); err != nil {
+		return fmt.Errorf("导出链路失败: %v", err)
+	}
+	return nil
+}
+
+// Config 返回当前使用的配置
+func (a *App) Config() *config.Config {
+	return a.current.Load().cfg
+}
+
+// Reload 使用新配置替换当前配置，新配置的来源、过滤规则、模板或发布目标无法创建时保留原配置
+// 正在进行的运行继续使用原配置，下次运行开始生效
+func (a *App) Reload(cfg *config.Config) error {
+	if problems := services.CheckConfig(cfg); len(problems) > 0 {
+		return config.ValidationErrors(problems)
+	}
+	old := a.current.Load()
+	p, err := a.newPipeline(cfg, old)
+	if err != nil {
+		return err
+	}
+	if cfg.HTTPAddr != old.cfg.HTTPAddr || cfg.DBHost != old.cfg.DBHost || cfg.DBPort != old.cfg.DBPort ||
+		cfg.DBUser != old.cfg.DBUser || cfg.DBPassword != old.cfg.DBPassword || cfg.DBName != old.cfg.DBName ||
+		cfg.Log != old.cfg.Log || !reflect.DeepEqual(cfg.Tracing, old.cfg.Tracing) {
+		slog.Warn("http_addr、数据库、日志和链路追踪配置的修改需要重启后生效")
+	}
+	a.current.Store(p)
+	if p.aiService != old.aiService {
+		// API 密钥已修改，等待正在进行的运行结束后关闭原来的 AI 服务
+		go func() {
+			a.runMu.Lock()
+			defer a.runMu.Unlock()
+			if err := old.aiService.Close(); err != nil {
+				slog.Warn("关闭AI服务失败", "error", err)
+			}
+		}()
+	}
+	return nil
+}
+
+// Run 运行所有启用的来源，配置了运行间隔且未到间隔的来源、当天精选已经发布的来源跳过
+// ctx 取消时中断当前来源的运行，不再运行其余来源
+func (a *App) Run(ctx context.Context) {
+	a.runMu.Lock()
+	defer a.runMu.Unlock()
+
+	// 每次运行使用同一份配置，运行中重新加载的配置从下次运行开始生效
+	p := a.current.Load()
+	runID := newRunID()
+	ctx = services.WithLogAttrs(ctx, slog.String(services.LogRunID, runID))
+	ctx = services.WithMetrics(ctx, a.metrics)
+	// 各服务在 ctx 所在的链路下创建 span
+	ctx, span := a.tracerProvider.Tracer(services.TracerName).Start(ctx, "run", trace.WithAttributes(attribute.String(services.LogRunID, runID)))
+	defer span.End()
+	for _, runner := range p.runners {
+		if ctx.Err() != nil {
+			return
+		}
+		name := runner.source.Name()
+		if runner.interval > 0 && !a.lastRun[name].IsZero() && time.Since(a.lastRun[name]) < runner.interval {
+			continue
+		}
+		a.lastRun[name] = time.Now()
+		sourceCtx := services.WithLogAttrs(ctx, slog.String(services.LogSource, name))
+		// 精选 Pid 按天生成，当天已发布时不再重复总结和发布
+		if a.published(sourceCtx, p, runner.spec.Pid(time.Now())) {
+			continue
+		}
+		report := services.NewReport(runID, name, time.Now())
+		sourceCtx = services.WithReport(sourceCtx, report)
+		err := fetchAndProcess(sourceCtx, p, runner)
+		a.finishReport(sourceCtx, p, report.Finish(err, time.Now()))
+	}
+}
+
+// published 判断精选是否已经发布，查询失败时按未发布处理
+func (a *App) published(ctx context.Context, p *pipeline, pid string) bool {
+	ctx, cancel := withTimeout(ctx, p.cfg.Timeouts.Fetch)
+	defer cancel()
+	published, err := a.ReportRepo.IsPublished(ctx, pid)
+	if err != nil {
+		slog.ErrorContext(ctx, "查询精选发布状态失败", "pid", pid, "error", err)
+		return false
+	}
+	if published {
+		slog.InfoContext(ctx, "今日精选已发布，跳过", "pid", pid)
+	}
+	return published
+}
+
+// finishReport 保存运行报告，失败次数超过阈值或没有发布精选时发送告警
+// 收到退出信号而中断的运行只保存报告，不告警
+func (a *App) finishReport(ctx context.Context, p *pipeline, report *models.RunReport) {
+	if ctx.Err() == nil {
+		report.Alert = p.alerter.Reason(report)
+	}
+	ctx, cancel := withTimeout(context.WithoutCancel(ctx), p.cfg.Timeouts.Publish)
+	defer cancel()
+	if err := a.ReportRepo.SaveReport(ctx, report); err != nil {
+		slog.ErrorContext(ctx, "保存运行报告失败", "error", err)
+	}
+	if report.Alert == "" {
+		return
+	}
+	slog.WarnContext(ctx, "运行异常，发送告警", "reason", report.Alert, "failures", len(report.Failures))
+	if err := p.alerter.Send(ctx, report); err != nil {
+		slog.ErrorContext(ctx, "发送告警失败", "error", err)
+	}
+}
+
+// PushMetrics 将运行指标推送到配置的 Pushgateway，未配置 metrics.push_url 时跳过
+func (a *App) PushMetrics(ctx context.Context) error {
+	cfg := a.Config().Metrics
+	if cfg.PushURL == "" {
+		return nil
+	}
+	return a.metrics.Push(ctx, cfg.PushURL, cfg.Job)
+}
+
+// Handler 返回 HTTP 服务，提供订阅地址、邮件退订地址和 /metrics 指标，订阅配置使用当前生效的配置
+func (a *App) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", a.metrics.Handler())
+	mux.Handle("/unsubscribe", services.UnsubscribeHandler(a.SubscriberRepo))
+	mux.HandleFunc("/feeds/", func(w http.ResponseWriter, r *http.Request) {
+		services.NewFeedService(a.Config().Feed).Handler(a.DigestRepo).ServeHTTP(w, r)
+	})
+	return mux
+}
+
+// summarizer 为文章生成总结，默认为 services.AIService
+type summarizer interface {
+	GenerateSummary(ctx context.Context, story *models.Story, customPrompt string) error
+	Close() error
+}
+
+// pipeline 按一份配置创建的 AI 服务和各来源，重新加载配置时整体替换
+type pipeline struct {
+	cfg       *config.Config
+	aiService summarizer
+	// 两篇文章总结之间的间隔，防止 API 频率限制
+	summaryInterval time.Duration
+	alerter         *services.Alerter
+	runners         []sourceRunner
+}
+
+// sourceRunner 一个启用的来源及其精选格式、过滤、排序和发布目标
+type sourceRunner struct {
+	source services.Source
+	spec   services.DigestSpec
+	// 自定义总结提示词，为空时按文章类型选择
+	prompt string
+	// 运行间隔，为 0 时每次运行
+	interval   time.Duration
+	filter     *services.StoryFilter
+	selector   *services.StorySelector
+	publishers []services.Publisher
+}
+
+// newPipeline 按配置创建 AI 服务和启用的来源，prev 为当前使用的 pipeline，API 密钥未变时复用其 AI 服务
+func (a *App) newPipeline(cfg *config.Config, prev *pipeline) (_ *pipeline, err error) {
+	p := &pipeline{cfg: cfg, alerter: services.NewAlerter(cfg), summaryInterval: 3 * time.Second}
+	if prev != nil && prev.cfg.GeminiAPIKey == cfg.GeminiAPIKey {
+		p.aiService = prev.aiService
+	} else {
+		aiService, err := services.NewAIService(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("初始化AI服务失败: %v", err)
+		}
+		p.aiService = aiService
+		// 其余部分创建失败时关闭新建的 AI 服务
+		defer func() {
+			if err != nil {
+				aiService.Close()
+			}
+		}()
+	}
+
+	// 初始化启用的文章来源及其发布目标
+	for _, name := range cfg.EnabledSources {
+		sourceConfig := cfg.Source(name)
+		source, err := services.NewSource(cfg, name)
+		if err != nil {
+			return nil, fmt.Errorf("初始化文章来源失败: %v", err)
+		}
+		filter, err := services.NewStoryFilter(*sourceConfig.Filters)
+		if err != nil {
+			return nil, fmt.Errorf("初始化过滤规则失败: %v", err)
+		}
+		publishers, err := services.NewPublishers(cfg, name, a.StoryRepo, a.DigestRepo, a.SubscriberRepo)
+		if err != nil {
+			return nil, fmt.Errorf("初始化发布目标失败: %v", err)
+		}
+		spec := services.DigestSpecs[name]
+		if sourceConfig.Template != "" {
+			if spec.StoryTemplate, err = services.ParseStoryTemplate(sourceConfig.Template); err != nil {
+				return nil, fmt.Errorf("初始化来源 %s 失败: %v", name, err)
+			}
+		}
+		p.runners = append(p.runners, sourceRunner{
+			source:     source,
+			spec:       spec,
+			prompt:     sourceConfig.Prompt,
+			interval:   time.Duration(sourceConfig.Interval) * time.Minute,
+			filter:     filter,
+			selector:   services.NewStorySelector(cfg, name, a.DigestRepo),
+			publishers: publishers,
+		})
+	}
+	return p, nil
+}
+
+// fetchAndProcess 获取来源的热门文章，生成中文总结并发布精选
+// 整次运行和获取、总结、发布各阶段分别按 p.cfg.Timeouts 设置超时，日志带有阶段和耗时，各阶段和每篇文章记录为 span
+// 返回导致运行提前结束的错误，单篇文章和单个发布目标的失败记录在运行报告中
+func fetchAndProcess(ctx context.Context, p *pipeline, runner sourceRunner) (runErr error) {
+	timeouts := p.cfg.Timeouts
+	ctx, cancel := withTimeout(ctx, timeouts.Run)
+	defer cancel()
+
+	source, spec := runner.source, runner.spec
+	ctx, span := services.StartSpan(ctx, "source", trace.WithAttributes(attribute.String(services.LogSource, source.Name())))
+	defer func() { services.EndSpan(span, runErr) }()
+	report := services.ReportFrom(ctx)
+	runStart := time.Now()
+	slog.InfoContext(ctx, "开始运行", "name", spec.Name)
+
+	// 获取热门文章
+	fetchCtx, fetchSpan := startStage(ctx, "fetch")
+	fetchCtx, cancelFetch := withTimeout(fetchCtx, timeouts.Fetch)
+	fetchStart := time.Now()
+	stories, err := collectStories(fetchCtx, source, runner.filter, runner.selector)
+	cancelFetch()
+	services.EndSpan(fetchSpan, err)
+	if err != nil {
+		runErr = err
+		slog.ErrorContext(withStage(ctx, "fetch"), "获取热门文章失败", services.LogDuration, time.Since(fetchStart).Milliseconds(), "error", err)
+		return
+	}
+	slog.InfoContext(withStage(ctx, "fetch"), "获取文章完成", "stories", len(stories), services.LogDuration, time.Since(fetchStart).Milliseconds())
+
+	var summarized []models.Story
+	// 为每篇文章生成中文总结
+	summaryStageCtx, summarySpan := startStage(ctx, "summary")
+	for i := range stories {
+		if ctx.Err() != nil {
+			break
+		}
+		storyCtx, storySpan := startStory(summaryStageCtx, stories[i])
+		summaryCtx, cancelSummary := withTimeout(storyCtx, timeouts.Summary)
+		summaryStart := time.Now()
+		err := p.aiService.GenerateSummary(summaryCtx, &stories[i], runner.prompt)
+		cancelSummary()
+		services.EndSpan(storySpan, err)
+		if err != nil {
+			countStories(ctx, source.Name(), services.StoryFailed, 1)
+			report.Fail("summary", stories[i], err)
+			slog.ErrorContext(storyCtx, "生成文章总结失败", "title", stories[i].Title, services.LogDuration, time.Since(summaryStart).Milliseconds(), "error", err)
+			continue
+		}
+		countStories(ctx, source.Name(), services.StorySummarized, 1)
+		slog.InfoContext(storyCtx, "生成文章总结完成", "title", stories[i].Title, services.LogDuration, time.Since(summaryStart).Milliseconds())
+		summarized = append(summarized, stories[i])
+		// 每次分析之间等待，防止api频率限制
+		select {
+		case <-ctx.Done():
+		case <-time.After(p.summaryInterval):
+		}
+	}
+	summarySpan.End()
+	if runErr = ctx.Err(); runErr != nil {
+		slog.WarnContext(ctx, "运行中断", "error", runErr)
+		return
+	}
+	if len(summarized) == 0 {
+		runErr = errors.New("没有生成任何文章总结")
+		slog.ErrorContext(ctx, "没有生成任何文章总结", services.LogDuration, time.Since(runStart).Milliseconds())
+		return
+	}
+
+	// 发布到所有配置的目标
+	digest := spec.BuildDigest(source.Name(), summarized, time.Now())
+	publishCtx, publishSpan := startStage(ctx, "publish")
+	publishCtx, cancelPublish := withTimeout(publishCtx, timeouts.Publish)
+	defer cancelPublish()
+	results := services.PublishAll(publishCtx, runner.publishers, digest)
+	publishSpan.End()
+	services.MetricsFrom(ctx).Published(source.Name(), results, time.Now())
+	report.Published(digest.Pid, results)
+	// 至少一个发布目标成功后才记录精选历史和确认文章，全部失败时下次运行重新获取
+	if services.AnyPublished(results) {
+		runner.selector.Record(ctx, digest)
+		if committer, ok := source.(services.StoryCommitter); ok {
+			if err := committer.Commit(ctx, digest.Stories); err != nil {
+				slog.ErrorContext(ctx, "确认已发布文章失败", "error", err)
+			}
+		}
+	}
+
+	slog.InfoContext(ctx, "运行完成", "pid", digest.Pid, "stories", len(summarized), services.LogDuration, time.Since(runStart).Milliseconds())
+	return nil
+}
+
+// collectStories 获取文章并过滤、排序选出本期文章
+// 支持候选列表的来源先按元数据过滤和排序，只为选中的文章获取正文
+func collectStories(ctx context.Context, source services.Source, filter *services.StoryFilter, selector *services.StorySelector) ([]models.Story, error) {
+	now := time.Now()
+	candidateSource, ok := source.(services.CandidateSource)
+	if !ok {
+		stories, err := source.FetchTopStories(ctx)
+		if err != nil {
+			return nil, err
+		}
+		countStories(ctx, source.Name(), services.StoryFetched, len(stories))
+		kept := filter.Apply(withStage(ctx, "filter"), stories, now)
+		countStories(ctx, source.Name(), services.StoryFiltered, len(stories)-len(kept))
+		return selector.Select(withStage(ctx, "select"), source.Name(), kept, now), nil
+	}
+
+	candidates, err := candidateSource.FetchCandidates(ctx, selector.Candidates())
+	if err != nil {
+		return nil, err
+	}
+	countStories(ctx, source.Name(), services.StoryFetched, len(candidates))
+	kept := filter.Apply(withStage(ctx, "filter"), candidates, now)
+	countStories(ctx, source.Name(), services.StoryFiltered, len(candidates)-len(kept))
+	candidates = kept
+	selected := selector.Select(withStage(ctx, "select"), source.Name(), candidates, now)
+
+	var stories []models.Story
+	for i := range selected {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		storyCtx, span := startStory(ctx, selected[i])
+		err := candidateSource.FetchContent(storyCtx, &selected[i])
+		services.EndSpan(span, err)
+		if err != nil {
+			countStories(ctx, source.Name(), services.StoryFailed, 1)
+			services.ReportFrom(ctx).Fail("fetch", selected[i], err)
+			slog.WarnContext(storyCtx, "获取文章内容失败", "title", selected[i].Title, "error", err)
+			continue
+		}
+		stories = append(stories, selected[i])
+	}
+	return stories, nil
+}
+
+// countStories 在运行指标和运行报告中记录文章处理数量
+func countStories(ctx context.Context, source, status string, count int) {
+	services.MetricsFrom(ctx).Stories(source, status, count)
+	services.ReportFrom(ctx).Stories(status, count)
+}
+
+// withStage 为日志添加当前阶段：fetch、filter、select、summary、publish
+func withStage(ctx context.Context, stage string) context.Context {
+	return services.WithLogAttrs(ctx, slog.String(services.LogStage, stage))
+}
+
+// startStage 为日志添加当前阶段，并为该阶段创建 span
+func startStage(ctx context.Context, stage string) (context.Context, trace.Span) {
+	return services.StartSpan(withStage(ctx, stage), stage)
+}
+
+// startStory 为日志添加文章ID，并为该文章创建 span
+func startStory(ctx context.Context, story models.Story) (context.Context, trace.Span) {
+	ctx = services.WithLogAttrs(ctx, slog.Int(services.LogStoryID, story.ID))
+	return services.StartSpan(ctx, "story", trace.WithAttributes(
+		attribute.Int(services.LogStoryID, story.ID),
+		attribute.String("title", story.Title),
+	))
+}
+
+// newRunID 生成一次运行的ID，用于关联同一次运行的日志
+func newRunID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// withTimeout 按秒数为 ctx 设置超时，seconds 为 0 时不限制
+func withTimeout(ctx context.Context, seconds int) (context.Context, context.CancelFunc) {
+	if seconds <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, time.Duration(seconds)*time.Second)
+}
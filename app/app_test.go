@@ -0,0 +1,187 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hacker-news-ai/config"
+	"github.com/hacker-news-ai/models"
+	"github.com/hacker-news-ai/services"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// fakeSource 返回固定文章的来源
+type fakeSource struct {
+	name    string
+	stories []models.Story
+}
+
+func (s *fakeSource) Name() string {
+	return s.name
+}
+
+func (s *fakeSource) FetchTopStories(ctx context.Context) ([]models.Story, error) {
+	return append([]models.Story(nil), s.stories...), nil
+}
+
+// fakeSummarizer 使用标题作为总结
+type fakeSummarizer struct{}
+
+func (fakeSummarizer) GenerateSummary(ctx context.Context, story *models.Story, customPrompt string) error {
+	story.Summary = "总结：" + story.Title
+	return nil
+}
+
+func (fakeSummarizer) Close() error {
+	return nil
+}
+
+// fakePublisher 记录收到的精选
+type fakePublisher struct {
+	mu      sync.Mutex
+	digests []*models.Digest
+}
+
+func (p *fakePublisher) Name() string {
+	return "fake"
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, digest *models.Digest) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.digests = append(p.digests, digest)
+	return nil
+}
+
+// newTestApp 创建使用假来源、假总结和假发布目标的应用
+// 数据库为 DryRun 模式，只生成 SQL 不连接数据库
+func newTestApp(t *testing.T, source *fakeSource, publisher *fakePublisher) *App {
+	t.Helper()
+	cfg := &config.Config{
+		GeminiAPIKey:    "test",
+		TopStoriesLimit: 2,
+		Timeouts:        config.TimeoutConfig{Run: 10, Fetch: 5, Summary: 5, Publish: 5},
+	}
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 dbname=test"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("创建数据库连接失败: %v", err)
+	}
+	a, err := NewWithDB(cfg, db)
+	if err != nil {
+		t.Fatalf("创建应用失败: %v", err)
+	}
+
+	filter, err := services.NewStoryFilter(*cfg.Source(source.name).Filters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := a.current.Load()
+	p.aiService = fakeSummarizer{}
+	p.summaryInterval = 0
+	p.runners = []sourceRunner{{
+		source:     source,
+		spec:       services.DigestSpecs["hn"],
+		filter:     filter,
+		selector:   services.NewStorySelector(cfg, source.name, a.DigestRepo),
+		publishers: []services.Publisher{publisher},
+	}}
+	return a
+}
+
+func TestAppsRunInParallel(t *testing.T) {
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		t.Run(fmt.Sprintf("app%d", i), func(t *testing.T) {
+			t.Parallel()
+			var stories []models.Story
+			for j := 0; j < 3; j++ {
+				stories = append(stories, models.Story{ID: i*10 + j, Title: fmt.Sprintf("app%d story%d", i, j), URL: "https://example.com", Time: now})
+			}
+			publisher := &fakePublisher{}
+			a := newTestApp(t, &fakeSource{name: fmt.Sprintf("hn%d", i), stories: stories}, publisher)
+
+			a.Run(context.Background())
+
+			// 每个应用只发布自己来源的文章，按 top_stories_limit 选出前两篇
+			publisher.mu.Lock()
+			defer publisher.mu.Unlock()
+			if len(publisher.digests) != 1 {
+				t.Fatalf("发布了 %d 期精选, want 1", len(publisher.digests))
+			}
+			digest := publisher.digests[0]
+			if digest.Source != fmt.Sprintf("hn%d", i) || len(digest.Stories) != 2 {
+				t.Fatalf("精选来源 %s，文章 %d 篇", digest.Source, len(digest.Stories))
+			}
+			for j, story := range digest.Stories {
+				if want := fmt.Sprintf("app%d story%d", i, j); story.Title != want || story.Summary != "总结："+want {
+					t.Errorf("第 %d 篇 = %q（%q）, want %q", j, story.Title, story.Summary, want)
+				}
+			}
+		})
+	}
+}
+
+// closingSummarizer 记录是否已关闭
+type closingSummarizer struct {
+	fakeSummarizer
+	closed chan struct{}
+}
+
+func (s *closingSummarizer) Close() error {
+	close(s.closed)
+	return nil
+}
+
+func TestReloadClosesReplacedAIService(t *testing.T) {
+	tests := []struct {
+		name   string
+		apiKey string
+		closed bool
+	}{
+		{"same key", "test", false},
+		{"new key", "rotated", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestApp(t, &fakeSource{name: "hn"}, &fakePublisher{})
+			old := &closingSummarizer{closed: make(chan struct{})}
+			a.current.Load().aiService = old
+
+			// 运行中不关闭原来的 AI 服务
+			a.runMu.Lock()
+			next := *a.Config()
+			next.GeminiAPIKey = tt.apiKey
+			if err := a.Reload(&next); err != nil {
+				t.Fatalf("重新加载配置失败: %v", err)
+			}
+			select {
+			case <-old.closed:
+				t.Fatal("运行结束前关闭了 AI 服务")
+			case <-time.After(50 * time.Millisecond):
+			}
+			a.runMu.Unlock()
+
+			wait := 100 * time.Millisecond
+			if tt.closed {
+				wait = time.Second
+			}
+			select {
+			case <-old.closed:
+				if !tt.closed {
+					t.Error("API 密钥未修改时关闭了 AI 服务")
+				}
+			case <-time.After(wait):
+				if tt.closed {
+					t.Error("API 密钥修改后未关闭原来的 AI 服务")
+				}
+			}
+			if tt.closed {
+				a.current.Load().aiService.Close()
+			}
+		})
+	}
+}
@@ -1,24 +1,51 @@
 package config
 
-import (
-	"encoding/json"
-	"fmt"
-	"os"
-	"sync"
-)
-
 type Config struct {
 	// Google Gemini API配置
 	GeminiAPIKey string `json:"gemini_api_key"`
 
 	// Hacker News API配置
 	HNAPIBaseURL string `json:"hn_api_base_url"`
+	// hn 来源读取的文章列表：top、best、new、ask、show、job，多个列表合并为一期精选
+	HNLists []string `json:"hn_lists"`
 	// Dev.to API配置
 	DevAPIBaseURL string `json:"dev_api_base_url"`
+	// Lobsters API配置
+	LobstersAPIBaseURL string `json:"lobsters_api_base_url"`
+	// Lobsters 文章列表：hottest 或 newest
+	LobstersList string `json:"lobsters_list"`
+	// Lobsters 每个帖子获取的讨论串数量
+	LobstersCommentThreads int `json:"lobsters_comment_threads"`
+	// Reddit 配置
+	Reddit RedditConfig `json:"reddit"`
+	// GitHub 趋势仓库配置
+	GitHub GitHubConfig `json:"github"`
+	// RSS/Atom 订阅源配置
+	RSS RSSConfig `json:"rss"`
+	// 启用的文章来源，按顺序运行
+	EnabledSources []string `json:"enabled_sources"`
+	// 按来源的独立配置，未配置的项使用下方的全局配置
+	Sources map[string]SourceConfig `json:"sources"`
+	// 按来源指定运行间隔（分钟），用于生成周刊等低频精选，未配置时使用 fetch_interval
+	SourceIntervals map[string]int `json:"source_intervals"`
+	// 按来源配置文章过滤规则
+	Filters map[string]FilterConfig `json:"filters"`
+	// 按来源配置精选排序，未配置的来源按原始顺序取前 top_stories_limit 篇
+	Selection map[string]SelectionConfig `json:"selection"`
 	// 每日获取的热门文章数量
 	TopStoriesLimit int `json:"top_stories_limit"`
-	// 抓取间隔（分钟）
+	// 抓取间隔（分钟），守护进程模式下使用
 	FetchInterval int `json:"fetch_interval"`
+	// 守护进程模式下 HTTP 服务监听地址
+	HTTPAddr string `json:"http_addr"`
+	// 运行各阶段的超时时间
+	Timeouts TimeoutConfig `json:"timeouts"`
+	// 日志输出配置
+	Log LogConfig `json:"log"`
+	// Prometheus 指标配置
+	Metrics MetricsConfig `json:"metrics"`
+	// OpenTelemetry 链路追踪配置
+	Tracing TracingConfig `json:"tracing"`
 
 	// 数据库配置
 	DBHost     string `json:"db_host"`
@@ -26,40 +53,442 @@ type Config struct {
 	DBUser     string `json:"db_user"`
 	DBPassword string `json:"db_password"`
 	DBName     string `json:"db_name"`
+
+	// 发布目标配置，按来源（hn、dev 等）指定发布目标列表，未配置时只发布到论坛
+	Publishers map[string][]string `json:"publishers"`
+	// Webhook 发布配置
+	Webhook WebhookConfig `json:"webhook"`
+	// Markdown 静态博客发布配置
+	Markdown MarkdownConfig `json:"markdown"`
+	// RSS/Atom/JSON Feed 订阅配置
+	Feed FeedConfig `json:"feed"`
+	// 邮件发送配置
+	SMTP SMTPConfig `json:"smtp"`
+	// 聊天群推送配置
+	Chat ChatConfig `json:"chat"`
+	// 运行失败告警配置
+	Alert AlertConfig `json:"alert"`
+
+	// 加载配置时读取的文件，用于监视配置变化
+	files []string
+}
+
+// RedditConfig Reddit 来源配置
+type RedditConfig struct {
+	APIBaseURL string `json:"api_base_url"`
+	// 帖子和评论页的网页地址
+	WebBaseURL string `json:"web_base_url"`
+	// 关注的 subreddit 列表
+	Subreddits []string `json:"subreddits"`
+	// 热门时间范围：day 或 week
+	TimeWindow string `json:"time_window"`
+	// 最低得票数
+	MinScore int `json:"min_score"`
+	// 每个帖子获取的热门讨论串数量
+	CommentThreads int `json:"comment_threads"`
+	// Reddit 要求设置可识别的 User-Agent
+	UserAgent string `json:"user_agent"`
+}
+
+// GitHubConfig GitHub 趋势仓库来源配置
+type GitHubConfig struct {
+	APIBaseURL string `json:"api_base_url"`
+	WebBaseURL string `json:"web_base_url"`
+	// 访问令牌，可选，用于提高 API 频率限制
+	Token string `json:"token"`
+	// 获取方式：trending 解析趋势页，search 搜索近期创建且 Star 最多的仓库
+	Mode string `json:"mode"`
+	// 语言过滤，为空时不限语言
+	Languages []string `json:"languages"`
+	// 统计周期：daily、weekly、monthly
+	Since string `json:"since"`
+}
+
+// MetricsConfig Prometheus 指标配置，守护进程模式下通过 /metrics 提供
+type MetricsConfig struct {
+	// Pushgateway 兼容的推送地址，设置后单次运行结束时推送指标
+	PushURL string `json:"push_url"`
+	// 推送时使用的 job 名称
+	Job string `json:"job"`
+}
+
+// TracingConfig OpenTelemetry 链路追踪配置，通过 OTLP/HTTP 导出，未设置 endpoint 时不记录
+type TracingConfig struct {
+	// OTLP/HTTP 接收地址，如 http://localhost:4318，链路发送到该地址的 /v1/traces
+	Endpoint string `json:"endpoint"`
+	// 导出时附加的请求头，如认证令牌
+	Headers map[string]string `json:"headers"`
+	// 上报的服务名称
+	ServiceName string `json:"service_name"`
+	// 采样比例，0-1，为 1 时记录每次运行
+	SampleRatio float64 `json:"sample_ratio"`
+}
+
+// LogConfig 日志输出配置
+type LogConfig struct {
+	// 输出格式：text 或 json
+	Format string `json:"format"`
+	// 日志级别：debug、info、warn、error
+	Level string `json:"level"`
+}
+
+// TimeoutConfig 运行各阶段的超时时间（秒），为 0 时不限制
+type TimeoutConfig struct {
+	// 单个来源一次运行的总时间
+	Run int `json:"run"`
+	// 获取、过滤和排序文章
+	Fetch int `json:"fetch"`
+	// 单篇文章生成总结，包括遇到 429 时的重试等待
+	Summary int `json:"summary"`
+	// 发布到所有目标
+	Publish int `json:"publish"`
+	// 守护进程退出时等待 HTTP 请求结束的时间
+	Shutdown int `json:"shutdown"`
+}
+
+// RSSConfig RSS/Atom 订阅源配置
+type RSSConfig struct {
+	// 订阅源列表
+	Feeds []RSSFeed `json:"feeds"`
+	// 抓取状态文件，记录每个订阅源的 ETag、Last-Modified 和已读条目
+	StatePath string `json:"state_path"`
+	// 忽略发布时间早于该小时数的条目，0 表示不限制
+	MaxAgeHours int `json:"max_age_hours"`
+}
+
+// RSSFeed 单个订阅源
+type RSSFeed struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// SourceConfig 单个来源的配置，未设置的项使用全局配置
+type SourceConfig struct {
+	// 每期精选的文章数量，默认 top_stories_limit
+	Limit int `json:"limit"`
+	// 候选文章数量，默认 selection.candidates，未配置排序时等于 limit
+	Candidates int `json:"candidates"`
+	// 运行间隔（分钟），默认 source_intervals 中的配置
+	Interval int `json:"interval"`
+	// 自定义总结提示词，{title} 和 {content} 分别替换为标题和内容
+	Prompt string `json:"prompt"`
+	// 自定义单篇文章在精选中的格式，使用 Go text/template 语法，字段同 Story
+	Template string `json:"template"`
+	// 从文件读取提示词和文章格式，相对路径以配置文件所在目录为准
+	PromptFile   string `json:"prompt_file"`
+	TemplateFile string `json:"template_file"`
+	// 发布目标列表，默认 publishers 中的配置
+	Publishers []string `json:"publishers"`
+	// 过滤规则，默认 filters 中的配置
+	Filters *FilterConfig `json:"filters"`
+	// 精选排序，默认 selection 中的配置
+	Selection *SelectionConfig `json:"selection"`
+}
+
+// FilterConfig 文章过滤规则，抓取后、生成总结前执行，未配置的规则不生效
+type FilterConfig struct {
+	// 只保留这些域名（含子域名）的文章
+	AllowDomains []string `json:"allow_domains"`
+	// 排除这些域名（含子域名）的文章
+	DenyDomains []string `json:"deny_domains"`
+	// 标题包含这些关键词（不区分大小写）的文章会被排除
+	DenyKeywords []string `json:"deny_keywords"`
+	// 标题匹配这些正则表达式的文章会被排除
+	DenyPatterns []string `json:"deny_patterns"`
+	// 最低评分
+	MinScore int `json:"min_score"`
+	// 最低评论数
+	MinComments int `json:"min_comments"`
+	// 最长发布时间（小时）
+	MaxAgeHours int `json:"max_age_hours"`
+	// 只保留这些类型的文章：story、job、repo
+	Kinds []string `json:"kinds"`
+}
+
+// SelectionConfig 精选排序配置，按权重为候选文章打分后选出最终文章
+type SelectionConfig struct {
+	// 候选文章数量，应大于 top_stories_limit
+	Candidates int `json:"candidates"`
+	// 各项指标的权重
+	Weights SelectionWeights `json:"weights"`
+	// 新颖度比较的历史天数，默认 7 天
+	NoveltyDays int `json:"novelty_days"`
+}
+
+// SelectionWeights 排序权重，为 0 的指标不参与打分
+type SelectionWeights struct {
+	// 评分
+	Score float64 `json:"score"`
+	// 评论速度（每小时评论数）
+	Velocity float64 `json:"velocity"`
+	// 新鲜度，发布越久得分越低
+	Freshness float64 `json:"freshness"`
+	// 话题多样性，与已选文章话题越相似扣分越多
+	Diversity float64 `json:"diversity"`
+	// 新颖度，与近期精选中的文章越相似得分越低
+	Novelty float64 `json:"novelty"`
+}
+
+// WebhookConfig Webhook 发布配置
+type WebhookConfig struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+}
+
+// MarkdownConfig Markdown 静态博客发布配置
+type MarkdownConfig struct {
+	// 输出目录，文章按 年/月 存放
+	Dir string `json:"dir"`
+	// 头信息格式：hugo、jekyll、hexo
+	Style string `json:"style"`
+	// 文章标签
+	Tags []string `json:"tags"`
+	// 额外的头信息字段
+	Extra map[string]string `json:"extra"`
+	// 索引页标题
+	IndexTitle string `json:"index_title"`
+	// 文章地址，写入头信息并用于索引页链接，支持 {year}、{month}、{day}、{slug} 占位符
+	Permalink string `json:"permalink"`
+}
+
+// FeedConfig RSS/Atom/JSON Feed 订阅配置
+type FeedConfig struct {
+	// 订阅文件输出目录，为空时只通过 HTTP 提供订阅
+	Dir string `json:"dir"`
+	// 站点地址
+	SiteURL string `json:"site_url"`
+	// 精选内容访问地址，{pid} 会被替换为精选的 Pid
+	ItemURL string `json:"item_url"`
+	// 订阅标题和描述
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// 订阅包含的精选数量
+	Limit int `json:"limit"`
+	// 是否按单篇文章生成订阅条目，默认每期精选一个条目
+	StoryItems bool `json:"story_items"`
 }
 
-var (
-	config *Config
-	once   sync.Once
-)
+// SMTPConfig 邮件发送配置
+type SMTPConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// 发件人地址和名称
+	From     string `json:"from"`
+	FromName string `json:"from_name"`
+	// 加密方式：tls（端口465）、starttls（端口587）、none（本地测试）
+	TLS string `json:"tls"`
+	// 跳过证书校验，仅用于测试环境
+	InsecureSkipVerify bool `json:"insecure_skip_verify"`
+	// 退订地址，{token} 替换为订阅用户的退订令牌，守护进程模式下由 /unsubscribe 处理
+	UnsubscribeURL string `json:"unsubscribe_url"`
+}
+
+// AlertConfig 运行失败告警配置，失败次数超过阈值或没有发布精选时发送运行报告
+type AlertConfig struct {
+	// 接收告警的 Webhook 地址，以 JSON 形式推送告警文本和运行报告，兼容 Slack 等接收 text 字段的 Webhook
+	WebhookURL string `json:"webhook_url"`
+	// 接收告警邮件的地址，使用 smtp 配置发送
+	Emails []string `json:"emails"`
+	// 获取内容、生成总结和发布的失败次数超过该值时告警
+	MaxFailures int `json:"max_failures"`
+}
 
-// LoadConfig 加载配置文件
+// ChatConfig 聊天群推送配置
+type ChatConfig struct {
+	// 推送方式：story 每篇文章一条消息，digest 每期精选一条消息
+	Mode string `json:"mode"`
+	// 每篇文章摘要的最大字符数
+	ExcerptLength int `json:"excerpt_length"`
+	// 精选内容访问地址，{pid} 会被替换为精选的 Pid
+	DigestURL string `json:"digest_url"`
+
+	Telegram TelegramConfig    `json:"telegram"`
+	Slack    ChatWebhookConfig `json:"slack"`
+	Discord  ChatWebhookConfig `json:"discord"`
+	Feishu   ChatWebhookConfig `json:"feishu"`
+	DingTalk ChatWebhookConfig `json:"dingtalk"`
+}
+
+// TelegramConfig Telegram 机器人配置
+type TelegramConfig struct {
+	BotToken   string `json:"bot_token"`
+	ChatID     string `json:"chat_id"`
+	APIBaseURL string `json:"api_base_url"`
+	// 推送方式，为空时使用 chat.mode
+	Mode string `json:"mode"`
+	// 两条消息之间的最小间隔（毫秒），为空时使用平台默认限制
+	IntervalMs int `json:"interval_ms"`
+}
+
+// ChatWebhookConfig Slack、Discord、飞书、钉钉等 Webhook 机器人配置
+type ChatWebhookConfig struct {
+	WebhookURL string `json:"webhook_url"`
+	// 飞书、钉钉的签名密钥
+	Secret string `json:"secret"`
+	// 推送方式，为空时使用 chat.mode
+	Mode string `json:"mode"`
+	// 两条消息之间的最小间隔（毫秒），为空时使用平台默认限制
+	IntervalMs int `json:"interval_ms"`
+}
+
+// LoadConfig 加载配置，优先级从低到高：默认配置、配置文件（JSON、YAML 或 TOML）、环境变量
+// configPath 为空时不读取配置文件，只使用默认配置和环境变量
+// 配置文件格式错误、包含未知配置项或未通过校验时，返回包含所有问题的 ValidationErrors
 func LoadConfig(configPath string) (*Config, error) {
-	// 读取配置文件
-	data, err := os.ReadFile(configPath)
+	cfg, problems, err := Check(configPath)
 	if err != nil {
-		return nil, fmt.Errorf("读取配置文件失败: %v", err)
+		return nil, err
+	}
+	if len(problems) > 0 {
+		return nil, problems
 	}
+	return cfg, nil
+}
 
-	once.Do(func() {
-		// 初始化默认配置
-		config = &Config{
-			HNAPIBaseURL:    "https://hacker-news.firebaseio.com/v0",
-			DevAPIBaseURL:   "https://dev.to/api",
-			TopStoriesLimit: 30,
-			FetchInterval:   60,
-		}
+// Check 读取并校验配置，返回配置及发现的所有问题，只有配置文件无法读取时返回错误
+func Check(configPath string) (*Config, ValidationErrors, error) {
+	cfg, problems, err := check(configPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg, problems, nil
+}
+
+// check 与 Check 相同，但配置文件无法读取时也返回配置，其中记录了已尝试读取的文件，用于监视配置变化
+func check(configPath string) (*Config, ValidationErrors, error) {
+	cfg := defaultConfig()
+	var problems ValidationErrors
 
-		// 解析JSON配置文件
-		if err := json.Unmarshal(data, config); err != nil {
-			fmt.Printf("解析配置文件失败: %v，将使用默认配置", err)
+	// 解析配置文件，支持 JSON、YAML 和 TOML
+	if configPath != "" {
+		data, positional, err := readConfigFile(configPath, &cfg.files)
+		if err != nil {
+			return cfg, nil, err
 		}
-	})
+		problems = append(problems, decodeConfig(data, positional, cfg)...)
+		if positional {
+			// 未经 loadConfigTree 转换的 JSON 文件在这里处理相对路径
+			cfg.resolveSourcePaths(configPath)
+		}
+	}
+
+	// 环境变量覆盖配置文件
+	problems = append(problems, applyEnv(cfg)...)
+
+	problems = append(problems, cfg.loadSourceFiles()...)
+	problems = append(problems, cfg.Validate()...)
+	return cfg, problems, nil
+}
+
+// defaultConfig 返回默认配置
+func defaultConfig() *Config {
+	return &Config{
+		HNAPIBaseURL:           "https://hacker-news.firebaseio.com/v0",
+		HNLists:                []string{"top"},
+		DevAPIBaseURL:          "https://dev.to/api",
+		LobstersAPIBaseURL:     "https://lobste.rs",
+		LobstersList:           "hottest",
+		LobstersCommentThreads: 10,
+		EnabledSources:         []string{"hn", "dev"},
+		Reddit: RedditConfig{
+			APIBaseURL:     "https://www.reddit.com",
+			WebBaseURL:     "https://www.reddit.com",
+			Subreddits:     []string{"programming"},
+			TimeWindow:     "day",
+			MinScore:       50,
+			CommentThreads: 10,
+			UserAgent:      "hacker-news-ai/1.0 (+https://github.com/TwoThreeWang/hacker-news-ai)",
+		},
+		RSS: RSSConfig{
+			StatePath:   "data/rss_state.json",
+			MaxAgeHours: 168,
+		},
+		GitHub: GitHubConfig{
+			APIBaseURL: "https://api.github.com",
+			WebBaseURL: "https://github.com",
+			Mode:       "trending",
+			Since:      "daily",
+		},
+		TopStoriesLimit: 30,
+		FetchInterval:   60,
+		HTTPAddr:        ":8080",
+		Timeouts: TimeoutConfig{
+			Run:      1800,
+			Fetch:    600,
+			Summary:  180,
+			Publish:  300,
+			Shutdown: 30,
+		},
+		Log: LogConfig{
+			Format: "text",
+			Level:  "info",
+		},
+		Metrics: MetricsConfig{
+			Job: "hacker-news-ai",
+		},
+		Tracing: TracingConfig{
+			ServiceName: "hacker-news-ai",
+			SampleRatio: 1,
+		},
+		Alert: AlertConfig{
+			MaxFailures: 3,
+		},
+		Markdown: MarkdownConfig{
+			Dir:        "posts",
+			Style:      "hugo",
+			IndexTitle: "AI 中文精选归档",
+			Permalink:  "/{year}/{month}/{slug}/",
+		},
+		Feed: FeedConfig{
+			Title:       "AI 中文精选",
+			Description: "Hacker News 与 DEV 社区热门文章的 AI 中文解读",
+			Limit:       20,
+		},
+		SMTP: SMTPConfig{
+			Port:     587,
+			FromName: "AI 中文精选",
+			TLS:      "starttls",
+		},
+		Chat: ChatConfig{
+			Mode:          "digest",
+			ExcerptLength: 200,
+		},
+	}
+}
 
-	return config, nil
+// Source 返回来源的配置，sources 中未设置的项使用全局配置，Filters 总是非空
+func (c *Config) Source(name string) SourceConfig {
+	source := c.Sources[name]
+	if source.Limit == 0 {
+		source.Limit = c.TopStoriesLimit
+	}
+	if source.Interval == 0 {
+		source.Interval = c.SourceIntervals[name]
+	}
+	if len(source.Publishers) == 0 {
+		source.Publishers = c.Publishers[name]
+	}
+	if source.Filters == nil {
+		filters := c.Filters[name]
+		source.Filters = &filters
+	}
+	if source.Selection == nil {
+		if selection, ok := c.Selection[name]; ok {
+			source.Selection = &selection
+		}
+	}
+	if source.Candidates == 0 && source.Selection != nil {
+		source.Candidates = source.Selection.Candidates
+	}
+	if source.Candidates < source.Limit {
+		source.Candidates = source.Limit
+	}
+	return source
 }
 
-// GetConfig 获取配置实例
-func GetConfig() *Config {
-	return config
+// Files 返回配置使用的文件，包括配置文件、include 的文件以及提示词和模板文件
+func (c *Config) Files() []string {
+	return c.files
 }
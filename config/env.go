@@ -0,0 +1,147 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix 环境变量前缀，配置项 smtp.password 对应 HNAI_SMTP_PASSWORD
+const EnvPrefix = "HNAI_"
+
+// applyEnv 使用环境变量覆盖配置，返回所有格式错误的环境变量
+func applyEnv(cfg *Config) ValidationErrors {
+	var problems ValidationErrors
+	walkEnv(reflect.ValueOf(cfg).Elem(), EnvPrefix, func(name string, field reflect.Value) {
+		value, ok, err := lookupEnv(name)
+		if err != nil {
+			problems = append(problems, err.Error())
+			return
+		}
+		if !ok {
+			return
+		}
+		if err := setFromEnv(field, value); err != nil {
+			problems = append(problems, fmt.Sprintf("环境变量 %s 格式错误: %v", name, err))
+		}
+	})
+	return problems
+}
+
+// walkEnv 遍历配置字段，按 json 标签生成环境变量名，嵌套配置用下划线连接
+func walkEnv(v reflect.Value, prefix string, fn func(name string, field reflect.Value)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if tag == "" || tag == "-" {
+			continue
+		}
+		name := prefix + strings.ToUpper(tag)
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			walkEnv(field, name+"_", fn)
+			continue
+		}
+		fn(name, field)
+	}
+}
+
+// lookupEnv 读取环境变量，NAME_FILE 指向的文件内容（如 Docker/Kubernetes secrets）与 NAME 等价，两者不能同时设置
+func lookupEnv(name string) (string, bool, error) {
+	value, ok := os.LookupEnv(name)
+	path, fileOK := os.LookupEnv(name + "_FILE")
+	if !fileOK {
+		return value, ok, nil
+	}
+	if ok {
+		return "", false, fmt.Errorf("环境变量 %s 和 %s_FILE 不能同时设置", name, name)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("读取 %s_FILE 失败: %v", name, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+// setFromEnv 按字段类型解析环境变量：字符串列表使用逗号分隔，映射和对象列表使用 JSON
+func setFromEnv(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() == reflect.String {
+			var items []string
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+			field.Set(reflect.ValueOf(items))
+			return nil
+		}
+		return json.Unmarshal([]byte(value), field.Addr().Interface())
+	default:
+		return json.Unmarshal([]byte(value), field.Addr().Interface())
+	}
+	return nil
+}
+
+// PrintEnvUsage 输出配置优先级说明和所有可用的环境变量
+func PrintEnvUsage(w io.Writer) {
+	fmt.Fprintf(w, `
+配置优先级（从低到高）：
+  1. 内置默认配置
+  2. 配置文件（-config 指定，默认读取 %sCONFIG 或 config/config.json，为空时不读取）
+  3. 环境变量 %s<配置项>，嵌套配置项用下划线连接，如 smtp.password 对应 %sSMTP_PASSWORD
+  4. 环境变量 %s<配置项>_FILE 从文件读取值，适用于 Docker/Kubernetes secrets，不能与同名环境变量同时设置
+
+字符串列表使用逗号分隔，映射和对象列表使用 JSON。
+
+可用的环境变量：
+`, EnvPrefix, EnvPrefix, EnvPrefix, EnvPrefix)
+	var cfg Config
+	walkEnv(reflect.ValueOf(&cfg).Elem(), EnvPrefix, func(name string, field reflect.Value) {
+		fmt.Fprintf(w, "  %-44s %s\n", name, envTypeName(field.Type()))
+	})
+}
+
+// envTypeName 环境变量的取值格式说明
+func envTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "字符串"
+	case reflect.Int, reflect.Int64:
+		return "整数"
+	case reflect.Bool:
+		return "true/false"
+	case reflect.Float64:
+		return "数字"
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.String {
+			return "逗号分隔列表"
+		}
+	}
+	return "JSON"
+}
@@ -0,0 +1,207 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileNames 未指定配置文件时按顺序查找的默认文件
+var ConfigFileNames = []string{"config/config.json", "config/config.yaml", "config/config.yml", "config/config.toml"}
+
+// maxIncludeDepth include 的最大嵌套层数，防止循环引用
+const maxIncludeDepth = 10
+
+// readConfigFile 读取 JSON、YAML 或 TOML 配置文件并合并 include 的文件，统一转换为 JSON
+// 返回的 positional 表示 JSON 中的偏移量是否对应原文件，用于在错误信息中给出行号
+// 读取的所有配置文件路径追加到 files，用于监视配置变化
+func readConfigFile(path string, files *[]string) (data []byte, positional bool, err error) {
+	data, err = os.ReadFile(path)
+	if err != nil {
+		return nil, false, fmt.Errorf("读取配置文件失败: %v", err)
+	}
+
+	// 不含 include 的 JSON 文件直接解析，保留错误位置
+	if configFormat(path) == "json" {
+		var probe map[string]json.RawMessage
+		if json.Unmarshal(data, &probe) != nil || probe["include"] == nil {
+			*files = append(*files, path)
+			return data, true, nil
+		}
+	}
+
+	merged, err := loadConfigTree(path, 0, files)
+	if err != nil {
+		return nil, false, err
+	}
+	data, err = json.Marshal(merged)
+	if err != nil {
+		return nil, false, fmt.Errorf("转换配置文件失败 [%s]: %v", path, err)
+	}
+	return data, false, nil
+}
+
+// loadConfigTree 解析配置文件为映射，先合并 include 的文件，再用当前文件覆盖
+func loadConfigTree(path string, depth int, files *[]string) (map[string]interface{}, error) {
+	if depth > maxIncludeDepth {
+		return nil, fmt.Errorf("配置文件 include 层数超过 %d，可能存在循环引用: %s", maxIncludeDepth, path)
+	}
+	*files = append(*files, path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %v", err)
+	}
+
+	tree := make(map[string]interface{})
+	switch configFormat(path) {
+	case "yaml":
+		err = yaml.Unmarshal(data, &tree)
+	case "toml":
+		err = toml.Unmarshal(data, &tree)
+	default:
+		err = json.Unmarshal(data, &tree)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析配置文件失败 [%s]: %v", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	resolveSourceFiles(tree, dir)
+
+	includes, err := includePaths(tree["include"])
+	if err != nil {
+		return nil, fmt.Errorf("配置文件 %s 的 include 格式错误: %v", path, err)
+	}
+	delete(tree, "include")
+
+	merged := make(map[string]interface{})
+	for _, include := range includes {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(dir, include)
+		}
+		included, err := loadConfigTree(include, depth+1, files)
+		if err != nil {
+			return nil, err
+		}
+		mergeTree(merged, included)
+	}
+	mergeTree(merged, tree)
+	return merged, nil
+}
+
+// configFormat 按扩展名判断配置文件格式，默认为 JSON
+func configFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".toml":
+		return "toml"
+	default:
+		return "json"
+	}
+}
+
+// includePaths 解析 include 配置，支持单个路径或路径列表
+func includePaths(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		var paths []string
+		for _, item := range v {
+			path, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("路径必须为字符串")
+			}
+			paths = append(paths, path)
+		}
+		return paths, nil
+	default:
+		return nil, fmt.Errorf("需要路径或路径列表")
+	}
+}
+
+// resolveSourceFiles 将 sources 中的 prompt_file 和 template_file 转换为相对配置文件所在目录的路径
+func resolveSourceFiles(tree map[string]interface{}, dir string) {
+	sources, ok := tree["sources"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	for _, value := range sources {
+		source, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, key := range []string{"prompt_file", "template_file"} {
+			if path, ok := source[key].(string); ok && path != "" && !filepath.IsAbs(path) {
+				source[key] = filepath.Join(dir, path)
+			}
+		}
+	}
+}
+
+// resolveSourcePaths 与 resolveSourceFiles 相同，用于直接解析到配置结构的 JSON 配置文件
+func (c *Config) resolveSourcePaths(configPath string) {
+	dir := filepath.Dir(configPath)
+	for name, source := range c.Sources {
+		for _, path := range []*string{&source.PromptFile, &source.TemplateFile} {
+			if *path != "" && !filepath.IsAbs(*path) {
+				*path = filepath.Join(dir, *path)
+			}
+		}
+		c.Sources[name] = source
+	}
+}
+
+// mergeTree 将 src 深度合并到 dst，同名映射递归合并，其他值直接覆盖
+func mergeTree(dst, src map[string]interface{}) {
+	for key, value := range src {
+		srcMap, srcIsMap := value.(map[string]interface{})
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			mergeTree(dstMap, srcMap)
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+// loadSourceFiles 读取 sources 中 prompt_file 和 template_file 指向的文件内容
+func (c *Config) loadSourceFiles() ValidationErrors {
+	var problems ValidationErrors
+	for _, name := range sortedKeys(c.Sources) {
+		source := c.Sources[name]
+		for _, file := range []struct {
+			key   string
+			path  string
+			value *string
+		}{
+			{"prompt", source.PromptFile, &source.Prompt},
+			{"template", source.TemplateFile, &source.Template},
+		} {
+			if file.path == "" {
+				continue
+			}
+			if *file.value != "" {
+				problems = append(problems, fmt.Sprintf("sources.%s 不能同时设置 %s 和 %s_file", name, file.key, file.key))
+				continue
+			}
+			c.files = append(c.files, file.path)
+			data, err := os.ReadFile(file.path)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("sources.%s.%s_file 读取失败: %v", name, file.key, err))
+				continue
+			}
+			*file.value = string(data)
+		}
+		c.Sources[name] = source
+	}
+	return problems
+}
@@ -0,0 +1,229 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writeFiles 在 dir 下写入测试用的配置文件
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// normalizeJSON 将 JSON 数据转换为通用结构，便于比较 YAML、TOML 转换后的结果
+func normalizeJSON(t *testing.T, v interface{}) interface{} {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestLoadConfigTreeMergesIncludes(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.yaml": `
+include:
+  - base.json
+  - sub/sources.toml
+db_host: main
+smtp:
+  port: 2525
+`,
+		"base.json": `{
+  "db_host": "base",
+  "db_user": "base",
+  "top_stories_limit": 10,
+  "smtp": {"host": "smtp.base", "port": 25}
+}`,
+		"sub/sources.toml": `
+include = "../shared.yaml"
+top_stories_limit = 20
+
+[sources.hn]
+prompt_file = "prompts/hn.txt"
+limit = 5
+`,
+		"shared.yaml": `
+db_user: shared
+sources:
+  hn:
+    candidates: 40
+    template_file: /etc/hn.tmpl
+  dev:
+    prompt_file: dev.txt
+`,
+	})
+
+	var files []string
+	tree, err := loadConfigTree(filepath.Join(dir, "config.yaml"), 0, &files)
+	if err != nil {
+		t.Fatalf("解析配置失败: %v", err)
+	}
+
+	// 后 include 的文件覆盖先 include 的文件，当前文件覆盖所有 include 的文件，映射递归合并
+	want := normalizeJSON(t, map[string]interface{}{
+		"db_host":           "main",
+		"db_user":           "shared",
+		"top_stories_limit": 20,
+		"smtp":              map[string]interface{}{"host": "smtp.base", "port": 2525},
+		"sources": map[string]interface{}{
+			"hn": map[string]interface{}{
+				// 相对路径相对声明它的配置文件所在目录，绝对路径保持不变
+				"prompt_file":   filepath.Join(dir, "sub", "prompts", "hn.txt"),
+				"template_file": "/etc/hn.tmpl",
+				"candidates":    40,
+				"limit":         5,
+			},
+			"dev": map[string]interface{}{"prompt_file": filepath.Join(dir, "dev.txt")},
+		},
+	})
+	if got := normalizeJSON(t, tree); !reflect.DeepEqual(got, want) {
+		t.Errorf("合并结果 =\n%v\nwant\n%v", got, want)
+	}
+
+	wantFiles := []string{
+		filepath.Join(dir, "config.yaml"),
+		filepath.Join(dir, "base.json"),
+		filepath.Join(dir, "sub", "sources.toml"),
+		filepath.Join(dir, "shared.yaml"),
+	}
+	if !reflect.DeepEqual(files, wantFiles) {
+		t.Errorf("files = %v, want %v", files, wantFiles)
+	}
+}
+
+func TestLoadConfigTreeErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{"include cycle", map[string]string{
+			"config.json": `{"include": "other.yaml"}`,
+			"other.yaml":  "include: config.json\n",
+		}, "include 层数超过 10"},
+		{"missing include", map[string]string{
+			"config.json": `{"include": ["missing.json"]}`,
+		}, "读取配置文件失败"},
+		{"bad include", map[string]string{
+			"config.json": `{"include": 1}`,
+		}, "include 格式错误: 需要路径或路径列表"},
+		{"bad toml", map[string]string{
+			"config.json": `{"include": "bad.toml"}`,
+			"bad.toml":    "db_host = ",
+		}, "解析配置文件失败 ["},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tt.files)
+			var files []string
+			_, err := loadConfigTree(filepath.Join(dir, "config.json"), 0, &files)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want 包含 %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadConfigFilePositional(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"plain.json":   `{"db_host": "a"}`,
+		"include.json": `{"include": "plain.json"}`,
+		"config.yaml":  "db_host: a\n",
+	})
+	tests := []struct {
+		file       string
+		positional bool
+	}{
+		// 不含 include 的 JSON 文件保留原始内容，错误信息可以给出行号
+		{"plain.json", true},
+		{"include.json", false},
+		{"config.yaml", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			var files []string
+			data, positional, err := readConfigFile(filepath.Join(dir, tt.file), &files)
+			if err != nil {
+				t.Fatalf("读取配置失败: %v", err)
+			}
+			if positional != tt.positional {
+				t.Errorf("positional = %v, want %v", positional, tt.positional)
+			}
+			var cfg Config
+			if err := json.Unmarshal(data, &cfg); err != nil || cfg.DBHost != "a" {
+				t.Errorf("db_host = %q, err = %v", cfg.DBHost, err)
+			}
+		})
+	}
+}
+
+func TestCheckLoadsPromptFileRelativeToConfig(t *testing.T) {
+	required := "gemini_api_key: key\ndb_host: localhost\ndb_port: 5432\ndb_user: root\ndb_name: hn\n"
+	tests := []struct {
+		name     string
+		config   string
+		files    map[string]string
+		prompt   string
+		problems ValidationErrors
+	}{
+		// include 的文件中的相对路径相对该文件所在目录
+		{"include", "conf/config.yaml", map[string]string{
+			"conf/config.yaml":     "include: sources/hn.yaml\n" + required,
+			"conf/sources/hn.yaml": "sources:\n  hn:\n    prompt_file: hn.txt\n",
+			"conf/sources/hn.txt":  "总结 {title}：{content}",
+		}, filepath.Join("conf", "sources", "hn.txt"), nil},
+		// 不含 include 的 JSON 文件直接解析，不经过 loadConfigTree
+		{"plain json", "conf/config.json", map[string]string{
+			"conf/config.json": `{"gemini_api_key": "key", "db_host": "localhost", "db_port": 5432, "db_user": "root", "db_name": "hn",
+  "sources": {"hn": {"prompt_file": "prompts/hn.txt", "template_file": "/nonexistent/abs.tmpl"}}}`,
+			"conf/prompts/hn.txt": "总结 {title}：{content}",
+		}, filepath.Join("conf", "prompts", "hn.txt"), ValidationErrors{
+			// 绝对路径保持不变
+			"sources.hn.template_file 读取失败: open /nonexistent/abs.tmpl: no such file or directory",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tt.files)
+
+			cfg, problems, err := Check(filepath.Join(dir, tt.config))
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if !reflect.DeepEqual(problems, tt.problems) {
+				t.Fatalf("problems = %v, want %v", problems, tt.problems)
+			}
+			if got := cfg.Source("hn").Prompt; got != "总结 {title}：{content}" {
+				t.Errorf("prompt = %q", got)
+			}
+			// 提示词文件同样需要监视
+			if files := cfg.Files(); !slices.Contains(files, filepath.Join(dir, tt.prompt)) {
+				t.Errorf("files = %v, want 包含 %s", files, tt.prompt)
+			}
+		})
+	}
+}
@@ -0,0 +1,505 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ValidationErrors 配置检查发现的所有问题
+type ValidationErrors []string
+
+func (e ValidationErrors) Error() string {
+	return fmt.Sprintf("配置检查发现 %d 个问题:\n  - %s", len(e), strings.Join(e, "\n  - "))
+}
+
+// decodeConfig 解析配置文件，报告语法错误、类型错误和所有未知配置项
+// positional 为 false 时 data 由 YAML、TOML 或 include 合并转换而来，错误信息不包含行号
+func decodeConfig(data []byte, positional bool, cfg *Config) ValidationErrors {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) && positional {
+			line, col := position(data, syntaxErr.Offset)
+			return ValidationErrors{fmt.Sprintf("配置文件第 %d 行第 %d 列语法错误: %v", line, col, err)}
+		}
+		return ValidationErrors{fmt.Sprintf("解析配置文件失败: %v", err)}
+	}
+
+	problems := ValidationErrors(unknownFields(raw, reflect.TypeOf(*cfg), ""))
+	typeProblems := typeErrors(data, positional, reflect.TypeOf(*cfg))
+	problems = append(problems, typeProblems...)
+	// 标准库遇到类型错误后仍会继续解析其余配置项，类型错误已由 typeErrors 逐项报告
+	if err := json.Unmarshal(data, cfg); err != nil && len(typeProblems) == 0 {
+		problems = append(problems, fmt.Sprintf("解析配置文件失败: %v", err))
+	}
+	return problems
+}
+
+// typeErrors 按配置结构逐项检查取值类型，返回所有类型错误
+// 标准库只返回第一个类型错误，这里逐个读取 JSON token，positional 为 true 时报告每个错误的行号
+func typeErrors(data []byte, positional bool, t reflect.Type) []string {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var problems []string
+	report := func(path string, t reflect.Type, value string, offset int64) {
+		if positional {
+			// offset 为上一个 token 之后的位置，跳过空白和分隔符后为该值的开头
+			for offset < int64(len(data)) && strings.IndexByte(" \t\r\n:,", data[offset]) >= 0 {
+				offset++
+			}
+			line, col := position(data, offset)
+			problems = append(problems, fmt.Sprintf("配置项 %s 类型错误（第 %d 行第 %d 列）: 需要 %s，实际为 %s", path, line, col, t, value))
+			return
+		}
+		problems = append(problems, fmt.Sprintf("配置项 %s 类型错误: 需要 %s，实际为 %s", path, t, value))
+	}
+
+	var walk func(t reflect.Type, path string) error
+	walk = func(t reflect.Type, path string) error {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		if value, ok := mismatch(t, token); ok {
+			report(path, t, value, offset)
+			return skipValue(decoder, token)
+		}
+		switch token {
+		case json.Delim('{'):
+			for decoder.More() {
+				key, err := decoder.Token()
+				if err != nil {
+					return err
+				}
+				fieldType, ok := jsonFieldType(t, key.(string))
+				if !ok {
+					// 未知配置项由 unknownFields 报告
+					var skip json.RawMessage
+					if err := decoder.Decode(&skip); err != nil {
+						return err
+					}
+					continue
+				}
+				if err := walk(fieldType, joinPath(path, key.(string))); err != nil {
+					return err
+				}
+			}
+			_, err = decoder.Token()
+		case json.Delim('['):
+			for i := 0; decoder.More(); i++ {
+				if err := walk(t.Elem(), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+			_, err = decoder.Token()
+		}
+		return err
+	}
+	// 语法错误已在解析时报告
+	walk(t, "")
+	return problems
+}
+
+// mismatch 判断 JSON 值能否解析到 t 类型，不能时返回与标准库一致的值描述
+func mismatch(t reflect.Type, token json.Token) (string, bool) {
+	if t.Kind() == reflect.Interface || token == nil {
+		return "", false
+	}
+	switch value := token.(type) {
+	case json.Delim:
+		if value == '{' && (t.Kind() == reflect.Struct || t.Kind() == reflect.Map) {
+			return "", false
+		}
+		if value == '[' && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			return "", false
+		}
+		if value == '{' {
+			return "object", true
+		}
+		return "array", true
+	case string:
+		return "string", t.Kind() != reflect.String
+	case bool:
+		return "bool", t.Kind() != reflect.Bool
+	case json.Number:
+		switch t.Kind() {
+		case reflect.Float32, reflect.Float64:
+			return "", false
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if _, err := value.Int64(); err == nil {
+				return "", false
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if _, err := strconv.ParseUint(value.String(), 10, 64); err == nil {
+				return "", false
+			}
+		}
+		return "number " + value.String(), true
+	}
+	return "", false
+}
+
+// jsonFieldType 返回对象中 key 对应的字段类型，映射返回元素类型
+func jsonFieldType(t reflect.Type, key string) (reflect.Type, bool) {
+	if t.Kind() == reflect.Map {
+		return t.Elem(), true
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if strings.Split(t.Field(i).Tag.Get("json"), ",")[0] == key {
+			return t.Field(i).Type, true
+		}
+	}
+	return nil, false
+}
+
+// skipValue 跳过以 token 开头的值的剩余部分
+func skipValue(decoder *json.Decoder, token json.Token) error {
+	if token != json.Delim('{') && token != json.Delim('[') {
+		return nil
+	}
+	for depth := 1; depth > 0; {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+	}
+	return nil
+}
+
+// position 将字节偏移转换为行号和列号
+func position(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
+
+// unknownFields 对照配置结构查找所有未知配置项，并给出拼写相近的建议
+func unknownFields(raw interface{}, t reflect.Type, path string) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var problems []string
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		fields := make(map[string]reflect.Type)
+		var names []string
+		for i := 0; i < t.NumField(); i++ {
+			tag := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+			if tag != "" && tag != "-" {
+				fields[tag] = t.Field(i).Type
+				names = append(names, tag)
+			}
+		}
+		for _, key := range sortedKeys(object) {
+			fieldType, ok := fields[key]
+			if !ok {
+				problem := fmt.Sprintf("未知配置项 %s", joinPath(path, key))
+				if suggestion := closest(key, names); suggestion != "" {
+					problem += fmt.Sprintf("，是否为 %s？", joinPath(path, suggestion))
+				}
+				problems = append(problems, problem)
+				continue
+			}
+			problems = append(problems, unknownFields(object[key], fieldType, joinPath(path, key))...)
+		}
+	case reflect.Map:
+		object, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		for _, key := range sortedKeys(object) {
+			problems = append(problems, unknownFields(object[key], t.Elem(), joinPath(path, key))...)
+		}
+	case reflect.Slice:
+		items, ok := raw.([]interface{})
+		if !ok {
+			return nil
+		}
+		for i, item := range items {
+			problems = append(problems, unknownFields(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+	return problems
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// closest 返回编辑距离不超过 3 的最相近配置项
+func closest(key string, names []string) string {
+	best, bestDistance := "", 4
+	for _, name := range names {
+		if d := editDistance(key, name); d < bestDistance {
+			best, bestDistance = name, d
+		}
+	}
+	return best
+}
+
+// editDistance 计算两个字符串的编辑距离
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// Validate 检查必填项、地址格式、取值范围和配置项之间的依赖，返回所有问题
+func (c *Config) Validate() ValidationErrors {
+	var v validator
+
+	// 必填项
+	v.required("gemini_api_key", c.GeminiAPIKey)
+	v.required("db_host", c.DBHost)
+	v.required("db_user", c.DBUser)
+	v.required("db_name", c.DBName)
+	v.between("db_port", c.DBPort, 1, 65535)
+
+	// 地址
+	v.url("hn_api_base_url", c.HNAPIBaseURL, true)
+	v.url("dev_api_base_url", c.DevAPIBaseURL, true)
+	v.url("lobsters_api_base_url", c.LobstersAPIBaseURL, true)
+	v.url("reddit.api_base_url", c.Reddit.APIBaseURL, true)
+	v.url("reddit.web_base_url", c.Reddit.WebBaseURL, true)
+	v.url("github.api_base_url", c.GitHub.APIBaseURL, true)
+	v.url("github.web_base_url", c.GitHub.WebBaseURL, true)
+	v.url("webhook.url", c.Webhook.URL, false)
+	v.url("feed.site_url", c.Feed.SiteURL, false)
+	v.url("smtp.unsubscribe_url", c.SMTP.UnsubscribeURL, false)
+	v.url("chat.telegram.api_base_url", c.Chat.Telegram.APIBaseURL, false)
+	v.url("chat.slack.webhook_url", c.Chat.Slack.WebhookURL, false)
+	v.url("chat.discord.webhook_url", c.Chat.Discord.WebhookURL, false)
+	v.url("chat.feishu.webhook_url", c.Chat.Feishu.WebhookURL, false)
+	v.url("chat.dingtalk.webhook_url", c.Chat.DingTalk.WebhookURL, false)
+	v.url("metrics.push_url", c.Metrics.PushURL, false)
+	if c.Metrics.PushURL != "" {
+		v.required("metrics.job", c.Metrics.Job)
+	}
+	v.url("alert.webhook_url", c.Alert.WebhookURL, false)
+	for i, email := range c.Alert.Emails {
+		if _, err := mail.ParseAddress(email); err != nil {
+			v.add(fmt.Sprintf("alert.emails[%d] 不是有效的邮件地址: %q", i, email))
+		}
+	}
+	v.url("tracing.endpoint", c.Tracing.Endpoint, false)
+	if c.Tracing.Endpoint != "" {
+		v.required("tracing.service_name", c.Tracing.ServiceName)
+	}
+	for i, feed := range c.RSS.Feeds {
+		v.required(fmt.Sprintf("rss.feeds[%d].name", i), feed.Name)
+		v.url(fmt.Sprintf("rss.feeds[%d].url", i), feed.URL, true)
+	}
+
+	// 可选值
+	for i, list := range c.HNLists {
+		v.oneOf(fmt.Sprintf("hn_lists[%d]", i), list, "top", "best", "new", "ask", "show", "job")
+	}
+	v.oneOf("lobsters_list", c.LobstersList, "hottest", "newest")
+	v.oneOf("reddit.time_window", c.Reddit.TimeWindow, "hour", "day", "week", "month", "year", "all")
+	v.oneOf("github.mode", c.GitHub.Mode, "trending", "search")
+	v.oneOf("github.since", c.GitHub.Since, "daily", "weekly", "monthly")
+	v.oneOf("log.format", c.Log.Format, "text", "json")
+	v.oneOf("log.level", c.Log.Level, "debug", "info", "warn", "error")
+	v.oneOf("markdown.style", c.Markdown.Style, "hugo", "jekyll", "hexo")
+	v.oneOf("smtp.tls", c.SMTP.TLS, "tls", "starttls", "none")
+	v.oneOf("chat.mode", c.Chat.Mode, "digest", "story")
+	for _, platform := range []struct{ name, mode string }{
+		{"telegram", c.Chat.Telegram.Mode},
+		{"slack", c.Chat.Slack.Mode},
+		{"discord", c.Chat.Discord.Mode},
+		{"feishu", c.Chat.Feishu.Mode},
+		{"dingtalk", c.Chat.DingTalk.Mode},
+	} {
+		if platform.mode != "" {
+			v.oneOf("chat."+platform.name+".mode", platform.mode, "digest", "story")
+		}
+	}
+
+	// 取值范围
+	v.between("top_stories_limit", c.TopStoriesLimit, 1, 500)
+	v.between("fetch_interval", c.FetchInterval, 1, 1<<20)
+	v.between("feed.limit", c.Feed.Limit, 1, 1000)
+	v.between("smtp.port", c.SMTP.Port, 1, 65535)
+	v.between("chat.excerpt_length", c.Chat.ExcerptLength, 0, 4000)
+	v.between("lobsters_comment_threads", c.LobstersCommentThreads, 0, 100)
+	v.between("reddit.comment_threads", c.Reddit.CommentThreads, 0, 100)
+	v.between("rss.max_age_hours", c.RSS.MaxAgeHours, 0, 1<<20)
+	v.between("timeouts.run", c.Timeouts.Run, 0, 1<<20)
+	v.between("timeouts.fetch", c.Timeouts.Fetch, 0, 1<<20)
+	v.between("timeouts.summary", c.Timeouts.Summary, 0, 1<<20)
+	v.between("timeouts.publish", c.Timeouts.Publish, 0, 1<<20)
+	v.between("timeouts.shutdown", c.Timeouts.Shutdown, 0, 1<<20)
+	v.between("alert.max_failures", c.Alert.MaxFailures, 0, 1<<20)
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		v.add(fmt.Sprintf("tracing.sample_ratio 的值 %g 超出范围 0-1", c.Tracing.SampleRatio))
+	}
+
+	// 配置项之间的依赖
+	if len(c.EnabledSources) == 0 {
+		v.add("enabled_sources 不能为空")
+	}
+	for _, source := range c.EnabledSources {
+		if source == "rss" && len(c.RSS.Feeds) == 0 {
+			v.add("启用了 rss 来源，但 rss.feeds 为空")
+		}
+		if source == "reddit" && len(c.Reddit.Subreddits) == 0 {
+			v.add("启用了 reddit 来源，但 reddit.subreddits 为空")
+		}
+	}
+	if len(c.Alert.Emails) > 0 && (c.SMTP.Host == "" || c.SMTP.From == "") {
+		v.add("配置了 alert.emails，但未配置 smtp.host 或 smtp.from")
+	}
+	if c.Feed.ItemURL != "" && !strings.Contains(c.Feed.ItemURL, "{pid}") {
+		v.add("feed.item_url 需要包含 {pid} 占位符")
+	}
+	if !strings.Contains(c.Markdown.Permalink, "{slug}") {
+		v.add("markdown.permalink 需要包含 {slug} 占位符")
+	}
+	if c.SMTP.UnsubscribeURL != "" && !strings.Contains(c.SMTP.UnsubscribeURL, "{token}") {
+		v.add("smtp.unsubscribe_url 需要包含 {token} 占位符")
+	}
+	if c.Chat.DigestURL != "" && !strings.Contains(c.Chat.DigestURL, "{pid}") {
+		v.add("chat.digest_url 需要包含 {pid} 占位符")
+	}
+	for _, name := range sortedKeys(c.Filters) {
+		v.filters("filters."+name, c.Filters[name])
+	}
+	for _, name := range sortedKeys(c.Sources) {
+		source := c.Sources[name]
+		path := "sources." + name
+		if source.Limit < 0 || source.Candidates < 0 || source.Interval < 0 {
+			v.add(fmt.Sprintf("%s 的 limit、candidates 和 interval 不能为负数", path))
+		}
+		if source.Filters != nil {
+			v.filters(path+".filters", *source.Filters)
+		}
+		if source.Prompt != "" && (!strings.Contains(source.Prompt, "{title}") || !strings.Contains(source.Prompt, "{content}")) {
+			v.add(fmt.Sprintf("%s.prompt 需要包含 {title} 和 {content} 占位符", path))
+		}
+		// 同一项只能在一处配置，避免修改了其中一处却不生效
+		if _, ok := c.SourceIntervals[name]; ok && source.Interval != 0 {
+			v.add(fmt.Sprintf("source_intervals.%s 与 %s.interval 重复，只能配置其中一处", name, path))
+		}
+		if _, ok := c.Publishers[name]; ok && len(source.Publishers) > 0 {
+			v.add(fmt.Sprintf("publishers.%s 与 %s.publishers 重复，只能配置其中一处", name, path))
+		}
+		if _, ok := c.Filters[name]; ok && source.Filters != nil {
+			v.add(fmt.Sprintf("filters.%s 与 %s.filters 重复，只能配置其中一处", name, path))
+		}
+		if _, ok := c.Selection[name]; ok && source.Selection != nil {
+			v.add(fmt.Sprintf("selection.%s 与 %s.selection 重复，只能配置其中一处", name, path))
+		}
+	}
+
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return v.problems
+}
+
+// validator 收集校验问题
+type validator struct {
+	problems ValidationErrors
+}
+
+func (v *validator) add(problem string) {
+	v.problems = append(v.problems, problem)
+}
+
+func (v *validator) required(name, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.add(fmt.Sprintf("%s 不能为空", name))
+	}
+}
+
+func (v *validator) url(name, value string, required bool) {
+	if value == "" {
+		if required {
+			v.add(fmt.Sprintf("%s 不能为空", name))
+		}
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.add(fmt.Sprintf("%s 不是有效的 http(s) 地址: %q", name, value))
+	}
+}
+
+func (v *validator) oneOf(name, value string, options ...string) {
+	for _, option := range options {
+		if value == option {
+			return
+		}
+	}
+	v.add(fmt.Sprintf("%s 的值 %q 无效，可选 %s", name, value, strings.Join(options, "、")))
+}
+
+func (v *validator) between(name string, value, lo, hi int) {
+	if value < lo || value > hi {
+		v.add(fmt.Sprintf("%s 的值 %d 超出范围 %d-%d", name, value, lo, hi))
+	}
+}
+
+// filters 检查过滤规则的取值，正则表达式在创建过滤器时检查
+func (v *validator) filters(path string, filters FilterConfig) {
+	for _, kind := range filters.Kinds {
+		v.oneOf(path+".kinds", kind, "story", "job", "repo")
+	}
+	if filters.MinScore < 0 || filters.MinComments < 0 || filters.MaxAgeHours < 0 {
+		v.add(fmt.Sprintf("%s 的 min_score、min_comments 和 max_age_hours 不能为负数", path))
+	}
+}
+
+// sortedKeys 返回排序后的映射键，使问题按固定顺序输出
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
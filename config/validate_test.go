@@ -0,0 +1,186 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+// validConfig 返回通过校验的配置
+func validConfig() *Config {
+	cfg := defaultConfig()
+	cfg.GeminiAPIKey = "key"
+	cfg.DBHost = "localhost"
+	cfg.DBPort = 3306
+	cfg.DBUser = "root"
+	cfg.DBName = "hn"
+	return cfg
+}
+
+func TestDecodeConfigReportsAllProblems(t *testing.T) {
+	data := []byte(`{
+  "db_port": "x",
+  "timeouts": {"run": "x", "fetch": 1.5},
+  "enabled_source": ["hn"],
+  "smtp": {"hots": "a", "port": true},
+  "sources": {"rss": {"limit": "10", "filters": {"kinds": "story"}}},
+  "rss": {"feeds": [{"name": "a", "url": "https://a.example"}, {"name": 1}]}
+}`)
+	tests := []struct {
+		name       string
+		positional bool
+		want       ValidationErrors
+	}{
+		{"json", true, ValidationErrors{
+			"未知配置项 enabled_source，是否为 enabled_sources？",
+			"未知配置项 smtp.hots，是否为 smtp.host？",
+			"配置项 db_port 类型错误（第 2 行第 14 列）: 需要 int，实际为 string",
+			"配置项 timeouts.run 类型错误（第 3 行第 23 列）: 需要 int，实际为 string",
+			"配置项 timeouts.fetch 类型错误（第 3 行第 37 列）: 需要 int，实际为 number 1.5",
+			"配置项 smtp.port 类型错误（第 5 行第 33 列）: 需要 int，实际为 bool",
+			"配置项 sources.rss.limit 类型错误（第 6 行第 32 列）: 需要 int，实际为 string",
+			"配置项 sources.rss.filters.kinds 类型错误（第 6 行第 59 列）: 需要 []string，实际为 string",
+			"配置项 rss.feeds[1].name 类型错误（第 7 行第 73 列）: 需要 string，实际为 number 1",
+		}},
+		// YAML、TOML 转换后的内容没有对应的行号
+		{"converted", false, ValidationErrors{
+			"未知配置项 enabled_source，是否为 enabled_sources？",
+			"未知配置项 smtp.hots，是否为 smtp.host？",
+			"配置项 db_port 类型错误: 需要 int，实际为 string",
+			"配置项 timeouts.run 类型错误: 需要 int，实际为 string",
+			"配置项 timeouts.fetch 类型错误: 需要 int，实际为 number 1.5",
+			"配置项 smtp.port 类型错误: 需要 int，实际为 bool",
+			"配置项 sources.rss.limit 类型错误: 需要 int，实际为 string",
+			"配置项 sources.rss.filters.kinds 类型错误: 需要 []string，实际为 string",
+			"配置项 rss.feeds[1].name 类型错误: 需要 string，实际为 number 1",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			got := decodeConfig(data, tt.positional, cfg)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeConfig() =\n%v\nwant\n%v", got, tt.want)
+			}
+			// 类型正确的配置项仍然生效
+			if len(cfg.RSS.Feeds) != 2 || cfg.RSS.Feeds[0].URL != "https://a.example" {
+				t.Errorf("rss.feeds = %+v", cfg.RSS.Feeds)
+			}
+		})
+	}
+}
+
+func TestDecodeConfigSyntaxError(t *testing.T) {
+	got := decodeConfig([]byte("{\n  \"db_host\": \"a\",\n}"), true, defaultConfig())
+	want := ValidationErrors{"配置文件第 3 行第 2 列语法错误: invalid character '}' looking for beginning of object key string"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decodeConfig() = %v, want %v", got, want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *Config)
+		want   ValidationErrors
+	}{
+		{"valid", func(cfg *Config) {}, nil},
+		{"required", func(cfg *Config) {
+			cfg.GeminiAPIKey = ""
+			cfg.DBHost = " "
+		}, ValidationErrors{"gemini_api_key 不能为空", "db_host 不能为空"}},
+		{"ranges", func(cfg *Config) {
+			cfg.DBPort = 70000
+			cfg.TopStoriesLimit = 0
+			cfg.Timeouts.Run = -1
+			cfg.Tracing.SampleRatio = 1.5
+		}, ValidationErrors{
+			"db_port 的值 70000 超出范围 1-65535",
+			"top_stories_limit 的值 0 超出范围 1-500",
+			"timeouts.run 的值 -1 超出范围 0-1048576",
+			"tracing.sample_ratio 的值 1.5 超出范围 0-1",
+		}},
+		{"urls", func(cfg *Config) {
+			cfg.HNAPIBaseURL = ""
+			cfg.Webhook.URL = "ftp://example.com"
+			cfg.Feed.SiteURL = "example.com"
+			cfg.Chat.Slack.WebhookURL = "https://hooks.slack.com/x"
+		}, ValidationErrors{
+			"hn_api_base_url 不能为空",
+			`webhook.url 不是有效的 http(s) 地址: "ftp://example.com"`,
+			`feed.site_url 不是有效的 http(s) 地址: "example.com"`,
+		}},
+		{"options", func(cfg *Config) {
+			cfg.GitHub.Since = "yearly"
+			cfg.Log.Level = "trace"
+		}, ValidationErrors{
+			`github.since 的值 "yearly" 无效，可选 daily、weekly、monthly`,
+			`log.level 的值 "trace" 无效，可选 debug、info、warn、error`,
+		}},
+		{"dependencies", func(cfg *Config) {
+			cfg.EnabledSources = []string{"rss"}
+			cfg.Alert.Emails = []string{"ops@example.com"}
+			cfg.Feed.ItemURL = "https://example.com/p"
+			cfg.SMTP.UnsubscribeURL = "https://example.com/unsubscribe"
+		}, ValidationErrors{
+			"启用了 rss 来源，但 rss.feeds 为空",
+			"配置了 alert.emails，但未配置 smtp.host 或 smtp.from",
+			"feed.item_url 需要包含 {pid} 占位符",
+			"smtp.unsubscribe_url 需要包含 {token} 占位符",
+		}},
+		{"sources", func(cfg *Config) {
+			cfg.Sources = map[string]SourceConfig{
+				"hn": {Limit: -1, Prompt: "总结 {title}"},
+			}
+		}, ValidationErrors{
+			"sources.hn 的 limit、candidates 和 interval 不能为负数",
+			"sources.hn.prompt 需要包含 {title} 和 {content} 占位符",
+		}},
+		{"duplicate source settings", func(cfg *Config) {
+			cfg.SourceIntervals = map[string]int{"hn": 60, "dev": 120}
+			cfg.Publishers = map[string][]string{"hn": {"forum"}}
+			cfg.Filters = map[string]FilterConfig{"hn": {MinScore: 10}}
+			cfg.Selection = map[string]SelectionConfig{"hn": {Candidates: 60}}
+			cfg.Sources = map[string]SourceConfig{
+				"hn":  {Interval: 30, Publishers: []string{"feed"}, Filters: &FilterConfig{}, Selection: &SelectionConfig{}},
+				"dev": {Limit: 5},
+			}
+		}, ValidationErrors{
+			"source_intervals.hn 与 sources.hn.interval 重复，只能配置其中一处",
+			"publishers.hn 与 sources.hn.publishers 重复，只能配置其中一处",
+			"filters.hn 与 sources.hn.filters 重复，只能配置其中一处",
+			"selection.hn 与 sources.hn.selection 重复，只能配置其中一处",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.modify(cfg)
+			if got := cfg.Validate(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyEnvReportsAllProblems(t *testing.T) {
+	t.Setenv(EnvPrefix+"DB_PORT", "x")
+	t.Setenv(EnvPrefix+"TIMEOUTS_RUN", "y")
+	t.Setenv(EnvPrefix+"DB_HOST", "db")
+	t.Setenv(EnvPrefix+"SMTP_PASSWORD", "a")
+	t.Setenv(EnvPrefix+"SMTP_PASSWORD_FILE", "/nonexistent")
+
+	// 按配置结构的字段顺序报告
+	cfg := defaultConfig()
+	got := applyEnv(cfg)
+	want := ValidationErrors{
+		`环境变量 HNAI_TIMEOUTS_RUN 格式错误: strconv.ParseInt: parsing "y": invalid syntax`,
+		`环境变量 HNAI_DB_PORT 格式错误: strconv.ParseInt: parsing "x": invalid syntax`,
+		"环境变量 HNAI_SMTP_PASSWORD 和 HNAI_SMTP_PASSWORD_FILE 不能同时设置",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("applyEnv() =\n%v\nwant\n%v", got, want)
+	}
+	if cfg.DBHost != "db" {
+		t.Errorf("db_host = %q, want db", cfg.DBHost)
+	}
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+)
+
+// Watch 在配置文件、include 的文件、提示词或模板文件修改以及收到 SIGHUP 时重新加载配置
+// interval 为检查文件修改时间的间隔，为 0 时只响应 SIGHUP
+// cfg 为当前使用的配置，新配置通过校验后交给 apply 替换，未通过校验或 apply 失败时保留当前配置，
+// 并继续监视当前配置的文件和本次读取到的文件（如新 include 的文件），其中任一文件修改后重试
+// ctx 取消时停止监视并返回
+func Watch(ctx context.Context, configPath string, cfg *Config, interval time.Duration, apply func(*Config) error) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	watcher := newConfigWatcher(configPath, cfg, apply)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			slog.Info("收到 SIGHUP，重新加载配置")
+		case <-tick:
+			if !watcher.changed() {
+				continue
+			}
+			slog.Info("配置文件已修改，重新加载配置")
+		}
+		watcher.reload()
+	}
+}
+
+// configWatcher 记录监视的文件及其修改时间
+type configWatcher struct {
+	configPath string
+	cfg        *Config
+	apply      func(*Config) error
+	files      []string
+	mtimes     map[string]time.Time
+}
+
+func newConfigWatcher(configPath string, cfg *Config, apply func(*Config) error) *configWatcher {
+	w := &configWatcher{configPath: configPath, cfg: cfg, apply: apply}
+	w.watch(cfg.Files())
+	return w
+}
+
+// watch 设置监视的文件并记录当前修改时间
+func (w *configWatcher) watch(files []string) {
+	w.files = files
+	w.mtimes = modTimes(files)
+}
+
+// changed 判断监视的文件是否被修改
+func (w *configWatcher) changed() bool {
+	return !sameModTimes(w.mtimes, modTimes(w.files))
+}
+
+// reload 重新加载配置，返回是否已替换为新配置
+func (w *configWatcher) reload() bool {
+	next, problems, err := check(w.configPath)
+	if err == nil && len(problems) > 0 {
+		err = problems
+	}
+	if err == nil {
+		err = w.apply(next)
+	}
+	if err != nil {
+		slog.Error("重新加载配置失败，继续使用原配置", "error", err)
+		// 修正可能发生在当前配置的文件中，也可能发生在新配置刚 include 的文件中
+		w.watch(slices.Concat(w.cfg.Files(), next.Files()))
+		return false
+	}
+	// 新配置可能 include 了其他文件
+	w.cfg = next
+	w.watch(next.Files())
+	slog.Info("配置已重新加载", "files", next.Files())
+	return true
+}
+
+// modTimes 获取文件修改时间，文件不存在时记为零值
+func modTimes(files []string) map[string]time.Time {
+	mtimes := make(map[string]time.Time, len(files))
+	for _, file := range files {
+		var mtime time.Time
+		if info, err := os.Stat(file); err == nil {
+			mtime = info.ModTime()
+		}
+		mtimes[file] = mtime
+	}
+	return mtimes
+}
+
+// sameModTimes 判断两次获取的修改时间是否相同
+func sameModTimes(a, b map[string]time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for file, mtime := range a {
+		if other, ok := b[file]; !ok || !other.Equal(mtime) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestConfigWatcherRetriesAfterFailedReload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	base := "gemini_api_key: key\ndb_host: localhost\ndb_port: 5432\ndb_user: root\ndb_name: hn\n"
+	mtime := time.Now().Add(-time.Hour)
+	// write 写入文件并设置递增的修改时间，避免文件系统时间精度导致修改未被发现
+	write := func(name, content string) {
+		t.Helper()
+		writeFiles(t, dir, map[string]string{name: content})
+		mtime = mtime.Add(time.Second)
+		if err := os.Chtimes(filepath.Join(dir, name), mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("config.yaml", base)
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+
+	var applied []*Config
+	watcher := newConfigWatcher(path, cfg, func(next *Config) error {
+		applied = append(applied, next)
+		return nil
+	})
+	if watcher.changed() {
+		t.Fatal("文件未修改时报告了修改")
+	}
+
+	// 新 include 的文件有错误，保留原配置
+	write("config.yaml", base+"include: extra.yaml\n")
+	write("extra.yaml", "top_stories_limit: many\n")
+	if !watcher.changed() || watcher.reload() {
+		t.Fatal("包含错误的配置被加载")
+	}
+	if watcher.changed() {
+		t.Fatal("重新加载失败后没有新的修改时报告了修改")
+	}
+
+	// 只修正新 include 的文件也会重试
+	write("extra.yaml", "top_stories_limit: 5\n")
+	if !watcher.changed() {
+		t.Fatal("未监视新 include 的文件")
+	}
+	if !watcher.reload() || len(applied) != 1 || applied[0].TopStoriesLimit != 5 {
+		t.Fatalf("修正后未加载新配置: %d 次", len(applied))
+	}
+
+	// include 的文件不存在时，创建该文件后重试
+	write("extra.yaml", "include: missing.yaml\n")
+	if !watcher.changed() || watcher.reload() {
+		t.Fatal("include 不存在的文件时配置被加载")
+	}
+	write("missing.yaml", "top_stories_limit: 7\n")
+	if !watcher.changed() {
+		t.Fatal("未监视不存在的 include 文件")
+	}
+	if !watcher.reload() || len(applied) != 2 || applied[1].TopStoriesLimit != 7 {
+		t.Fatalf("创建 include 的文件后未加载新配置: %d 次", len(applied))
+	}
+}
+
+func TestWatchStopsOnCancel(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeFiles(t, dir, map[string]string{"config.yaml": "gemini_api_key: key\ndb_host: localhost\ndb_port: 5432\ndb_user: root\ndb_name: hn\n"})
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		Watch(ctx, path, cfg, 10*time.Millisecond, func(*Config) error { return nil })
+		close(done)
+	}()
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("ctx 取消后 Watch 未返回")
+	}
+}
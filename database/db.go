@@ -2,42 +2,33 @@ package database
 
 import (
 	"fmt"
-	"sync"
 
 	"github.com/hacker-news-ai/config"
+	"github.com/hacker-news-ai/models"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-var (
-	db   *gorm.DB
-	once sync.Once
-)
-
-// InitDB 初始化数据库连接
-func InitDB(cfg *config.Config) error {
-	var err error
-	once.Do(func() {
-		// 构建数据库连接字符串
-		dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=disable",
-			cfg.DBHost,
-			cfg.DBUser,
-			cfg.DBPassword,
-			cfg.DBName,
-			cfg.DBPort,
-		)
+// OpenDB 按配置连接数据库，并创建本项目自有的数据表
+func OpenDB(cfg *config.Config) (*gorm.DB, error) {
+	// 构建数据库连接字符串
+	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=disable",
+		cfg.DBHost,
+		cfg.DBUser,
+		cfg.DBPassword,
+		cfg.DBName,
+		cfg.DBPort,
+	)
 
-		// 连接数据库
-		db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
-		if err != nil {
-			return
-		}
-	})
-
-	return err
-}
+	// 连接数据库
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("连接数据库失败: %v", err)
+	}
 
-// GetDB 获取数据库连接实例
-func GetDB() *gorm.DB {
-	return db
+	// 自动创建本项目自有的数据表，论坛的数据表由论坛项目维护
+	if err := db.AutoMigrate(&models.Digest{}, &models.SelectionHistory{}, &models.Subscriber{}, &models.EmailDelivery{}, &models.RunReport{}); err != nil {
+		return nil, fmt.Errorf("创建数据表失败: %v", err)
+	}
+	return db, nil
 }
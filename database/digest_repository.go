@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hacker-news-ai/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DigestRepository 精选内容数据库操作封装
+type DigestRepository struct {
+	db *gorm.DB
+}
+
+// NewDigestRepository 创建精选内容数据库操作实例
+func NewDigestRepository(db *gorm.DB) *DigestRepository {
+	return &DigestRepository{
+		db: db,
+	}
+}
+
+// SaveDigest 保存精选内容，同一 Pid 重复保存时覆盖旧内容
+func (r *DigestRepository) SaveDigest(ctx context.Context, digest *models.Digest) error {
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "pid"}},
+		DoUpdates: clause.AssignmentColumns([]string{"title", "content", "cover", "stories", "created_at"}),
+	}).Create(digest).Error
+	if err != nil {
+		return fmt.Errorf("保存精选内容失败: %v", err)
+	}
+	return nil
+}
+
+// ListDigests 按时间倒序获取精选内容，source 为空时返回所有来源
+func (r *DigestRepository) ListDigests(ctx context.Context, source string, limit int) ([]models.Digest, error) {
+	var digests []models.Digest
+	query := r.db.WithContext(ctx).Order("created_at DESC").Limit(limit)
+	if source != "" {
+		query = query.Where("source = ?", source)
+	}
+	if err := query.Find(&digests).Error; err != nil {
+		return nil, fmt.Errorf("查询精选内容失败: %v", err)
+	}
+	return digests, nil
+}
+
+// SaveSelection 保存已发布精选的文章，同一 Pid 重复保存时覆盖旧记录
+func (r *DigestRepository) SaveSelection(ctx context.Context, history *models.SelectionHistory) error {
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "pid"}},
+		DoUpdates: clause.AssignmentColumns([]string{"stories", "created_at"}),
+	}).Create(history).Error
+	if err != nil {
+		return fmt.Errorf("保存精选历史失败: %v", err)
+	}
+	return nil
+}
+
+// ListSelectionsSince 获取指定时间之后已发布精选的文章，用于判断文章是否已收录
+func (r *DigestRepository) ListSelectionsSince(ctx context.Context, source string, since time.Time) ([]models.SelectionHistory, error) {
+	var histories []models.SelectionHistory
+	if err := r.db.WithContext(ctx).Where("source = ? AND created_at >= ?", source, since).Order("created_at DESC").Find(&histories).Error; err != nil {
+		return nil, fmt.Errorf("查询精选历史失败: %v", err)
+	}
+	return histories, nil
+}
@@ -0,0 +1,38 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hacker-news-ai/models"
+	"gorm.io/gorm"
+)
+
+// RunReportRepository 运行报告数据库操作封装
+type RunReportRepository struct {
+	db *gorm.DB
+}
+
+// NewRunReportRepository 创建运行报告数据库操作实例
+func NewRunReportRepository(db *gorm.DB) *RunReportRepository {
+	return &RunReportRepository{
+		db: db,
+	}
+}
+
+// SaveReport 保存运行报告
+func (r *RunReportRepository) SaveReport(ctx context.Context, report *models.RunReport) error {
+	if err := r.db.WithContext(ctx).Create(report).Error; err != nil {
+		return fmt.Errorf("保存运行报告失败: %v", err)
+	}
+	return nil
+}
+
+// IsPublished 判断指定 Pid 的精选是否已经发布到至少一个目标
+func (r *RunReportRepository) IsPublished(ctx context.Context, pid string) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&models.RunReport{}).Where("pid = ? AND published = ?", pid, true).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("查询运行报告失败: %v", err)
+	}
+	return count > 0, nil
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -14,16 +15,16 @@ type StoryRepository struct {
 }
 
 // NewStoryRepository 创建文章数据库操作实例
-func NewStoryRepository() *StoryRepository {
+func NewStoryRepository(db *gorm.DB) *StoryRepository {
 	return &StoryRepository{
-		db: GetDB(),
+		db: db,
 	}
 }
 
 // SaveStories 保存文章列表和博客内容到数据库
-func (r *StoryRepository) SaveStories(blogContent, title, Pid string) error {
+func (r *StoryRepository) SaveStories(ctx context.Context, blogContent, title, Pid string) error {
 	// 开启事务
-	tx := r.db.Begin()
+	tx := r.db.WithContext(ctx).Begin()
 	defer func() {
 		if r := recover(); r != nil {
 			tx.Rollback()
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hacker-news-ai/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SubscriberRepository 邮件订阅用户数据库操作封装
+type SubscriberRepository struct {
+	db *gorm.DB
+}
+
+// NewSubscriberRepository 创建邮件订阅用户数据库操作实例
+func NewSubscriberRepository(db *gorm.DB) *SubscriberRepository {
+	return &SubscriberRepository{
+		db: db,
+	}
+}
+
+// ListActiveSubscribers 获取订阅了指定来源的有效用户
+func (r *SubscriberRepository) ListActiveSubscribers(ctx context.Context, source string) ([]models.Subscriber, error) {
+	var subscribers []models.Subscriber
+	if err := r.db.WithContext(ctx).Where("active = ?", true).Order("id").Find(&subscribers).Error; err != nil {
+		return nil, fmt.Errorf("查询订阅用户失败: %v", err)
+	}
+
+	// 过滤未订阅该来源的用户
+	var result []models.Subscriber
+	for _, subscriber := range subscribers {
+		if subscriber.Sources == "" {
+			result = append(result, subscriber)
+			continue
+		}
+		for _, s := range strings.Split(subscriber.Sources, ",") {
+			if strings.TrimSpace(s) == source {
+				result = append(result, subscriber)
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// SetToken 保存订阅用户的退订令牌
+func (r *SubscriberRepository) SetToken(ctx context.Context, id int, token string) error {
+	if err := r.db.WithContext(ctx).Model(&models.Subscriber{}).Where("id = ?", id).Update("token", token).Error; err != nil {
+		return fmt.Errorf("保存退订令牌失败: %v", err)
+	}
+	return nil
+}
+
+// Unsubscribe 按退订令牌取消订阅，令牌不存在时返回 false
+func (r *SubscriberRepository) Unsubscribe(ctx context.Context, token string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Subscriber{}).Where("token = ?", token).Update("active", false)
+	if result.Error != nil {
+		return false, fmt.Errorf("取消订阅失败: %v", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ListDelivered 获取已收到指定精选的订阅用户 ID
+func (r *SubscriberRepository) ListDelivered(ctx context.Context, pid string) ([]int, error) {
+	var ids []int
+	if err := r.db.WithContext(ctx).Model(&models.EmailDelivery{}).Where("pid = ?", pid).Pluck("subscriber_id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("查询邮件发送记录失败: %v", err)
+	}
+	return ids, nil
+}
+
+// SaveDelivery 记录已发送的精选，重复记录时忽略
+func (r *SubscriberRepository) SaveDelivery(ctx context.Context, delivery *models.EmailDelivery) error {
+	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(delivery).Error; err != nil {
+		return fmt.Errorf("保存邮件发送记录失败: %v", err)
+	}
+	return nil
+}
@@ -1,151 +1,142 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"github.com/hacker-news-ai/app"
 	"github.com/hacker-news-ai/config"
-	"github.com/hacker-news-ai/database"
 	"github.com/hacker-news-ai/services"
 )
 
 func main() {
-	// 设置日志输出
-	log.SetOutput(os.Stdout)
-	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
+	// 子命令：config check 检查配置并报告所有问题
+	if len(os.Args) > 2 && os.Args[1] == "config" && os.Args[2] == "check" {
+		os.Exit(checkConfig(os.Args[3:]))
+	}
 
-	fmt.Println("AI 总结助手启动于:", time.Now().Format("2006-01-02 15:04:05"))
+	configPath := flag.String("config", defaultConfigPath(), "配置文件路径，为空时只使用默认配置和环境变量")
+	daemon := flag.Bool("daemon", false, "守护进程模式：按 fetch_interval 定时运行，并通过 HTTP 提供订阅")
+	watchInterval := flag.Duration("watch", 5*time.Second, "守护进程模式下检查配置文件修改的间隔，为 0 时只在收到 SIGHUP 时重新加载")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "用法: %s [选项]\n       %s config check [-config 路径]\n\n选项：\n", os.Args[0], os.Args[0])
+		flag.PrintDefaults()
+		config.PrintEnvUsage(flag.CommandLine.Output())
+	}
+	flag.Parse()
 
 	// 初始化配置
-	cfg, err := config.LoadConfig("config/config.json")
+	cfg, err := config.LoadConfig(*configPath)
 	if err != nil {
-		log.Fatalf("加载配置失败: %v", err)
-	}
-
-	// 初始化数据库
-	if err := database.InitDB(cfg); err != nil {
-		log.Fatalf("初始化数据库失败: %v", err)
+		fatal("加载配置失败", err)
 	}
 
-	// 初始化数据库操作服务
-	storyRepo := database.NewStoryRepository()
+	// 按配置设置日志格式和级别，标准库 log 的输出同样使用该日志
+	slog.SetDefault(services.NewLogger(cfg.Log, os.Stdout))
+	slog.Info("AI 总结助手启动", "config", *configPath, "daemon", *daemon)
 
-	// 初始化服务
-	hnService := services.NewHNService(cfg)
-	devService := services.NewDevService(cfg)
-	aiService, err := services.NewAIService(cfg)
+	// 创建应用：数据库、AI 服务、各来源及其发布目标
+	application, err := app.New(cfg)
 	if err != nil {
-		log.Fatalf("初始化AI服务失败: %v", err)
+		fatal("初始化失败", err)
 	}
 
-	// Hack News AI 助手运行
-	fetchAndProcessHN(hnService, aiService, storyRepo)
-	// dev.to AI 助手运行
-	fetchAndProcessDev(devService, aiService, storyRepo)
-
-	fmt.Println("AI 总结助手结束于:", time.Now().Format("2006-01-02 15:04:05"))
-}
+	// 收到 SIGINT 或 SIGTERM 时取消正在进行的请求，结束运行后退出
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-// fetchAndProcessHN 获取并处理HN热门文章
-func fetchAndProcessHN(hnService *services.HNService, aiService *services.AIService, storyRepo *database.StoryRepository) {
-	fmt.Println("Hacker News AI 助手启动于:", time.Now().Format("2006-01-02 15:04:05"))
-	// 获取热门文章
-	stories, err := hnService.FetchTopStories()
-	if err != nil {
-		log.Printf("获取热门文章失败: %v", err)
+	if !*daemon {
+		application.Run(ctx)
+		// 收到退出信号时仍推送已记录的指标和链路
+		pushCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		if err := application.PushMetrics(pushCtx); err != nil {
+			slog.Error("推送指标失败", "error", err)
+		}
+		if err := application.Shutdown(pushCtx); err != nil {
+			slog.Error("退出前导出数据失败", "error", err)
+		}
+		cancel()
+		slog.Info("AI 总结助手结束")
 		return
 	}
-	// 打印获取到的文章数量
-	fmt.Printf("Hacker News 获取到 %d 篇文章\n", len(stories))
-	blogContent := ""
-	// 为每篇文章生成中文总结
-	for i := range stories {
-		fmt.Printf("%d. %s\n", i, stories[i].Title)
-		if err := aiService.GenerateSummary(&stories[i]); err != nil {
-			log.Printf("Hacker News 生成文章总结失败 [%s]: %v", stories[i].Title, err)
-			continue
+
+	// 守护进程模式：监视配置变化，启动 HTTP 服务并定时运行
+	go config.Watch(ctx, *configPath, cfg, *watchInterval, application.Reload)
+	server := &http.Server{Addr: cfg.HTTPAddr, Handler: application.Handler()}
+	go func() {
+		slog.Info("HTTP 服务启动", "addr", cfg.HTTPAddr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fatal("HTTP 服务启动失败", err)
+		}
+	}()
+	for ctx.Err() == nil {
+		application.Run(ctx)
+		interval := time.Duration(application.Config().FetchInterval) * time.Minute
+		slog.Info("等待下次运行", "next_run", time.Now().Add(interval).Format(time.RFC3339))
+		select {
+		case <-ctx.Done():
+		case <-time.After(interval):
 		}
-		hnUrl := fmt.Sprintf("https://news.ycombinator.com/item?id=%d", stories[i].ID)
+	}
 
-		content := fmt.Sprintf("%s\n\n- 原文: [%s](%s)\n- Hacker News: [%s](%s)\n- 作者: %s\n- 评分: %d\n- 评论数: %d\n- 发布时间: %s\n\n---\n\n",
-			stories[i].Summary,
-			stories[i].Title,
-			stories[i].URL,
-			hnUrl,
-			hnUrl,
-			stories[i].By,
-			stories[i].Score,
-			stories[i].Descendants,
-			stories[i].Time.Format("2006-01-02 15:04:05"),
-		)
-		blogContent += content
-		// 每次分析间隔3秒，防止api频率限制
-		time.Sleep(3 * time.Second)
+	// 等待 HTTP 请求结束后退出，timeouts.shutdown 为 0 时不限制等待时间
+	shutdownCtx, cancel := context.WithCancel(context.Background())
+	if seconds := application.Config().Timeouts.Shutdown; seconds > 0 {
+		shutdownCtx, cancel = context.WithTimeout(context.Background(), time.Duration(seconds)*time.Second)
 	}
-	if blogContent == "" {
-		fmt.Println("Hacker News AI 助手运行错误:", time.Now().Format("2006-01-02 15:04:05"))
-		return
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("关闭 HTTP 服务失败", "error", err)
 	}
-	today := time.Now().Format("20060102")
-	blogContent = fmt.Sprintf("## Hacker News 中文精选 NO.%s\n\n一个基于 Hacker News 的中文日报项目，每天自动抓取 Hacker News 热门文章及评论，通过 AI 生成中文解读与总结，传递科技前沿信息。\n\n![Hacker News 中文精选](https://cdn.wangtwothree.com/imgur/f6uVgbS.jpeg)\n---\n\n%s", today, blogContent)
-	title := fmt.Sprintf("每日科技新知 NO.%s：Hacker News 中文解读，科技前沿热点速递", today)
-	Pid := fmt.Sprintf("HN%s", today)
-	// 保存文章和博客内容到数据库
-	if err := storyRepo.SaveStories(blogContent, title, Pid); err != nil {
-		log.Printf("保存数据到数据库失败: %v", err)
+	if err := application.Shutdown(shutdownCtx); err != nil {
+		slog.Error("退出前导出数据失败", "error", err)
 	}
+	slog.Info("AI 总结助手退出")
+}
 
-	//fmt.Println(blogContent)
-	fmt.Println("Hacker News AI 助手运行完成于:", time.Now().Format("2006-01-02 15:04:05"))
+// fatal 记录错误并退出
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
 
-// fetchAndProcessDev 获取并处理dev.to热门文章
-func fetchAndProcessDev(devService *services.DevService, aiService *services.AIService, storyRepo *database.StoryRepository) {
-	fmt.Println("dev.to AI 助手启动于:", time.Now().Format("2006-01-02 15:04:05"))
-	// 获取热门文章
-	stories, err := devService.FetchTopStories()
-	if err != nil {
-		log.Printf("获取dev.to热门文章失败: %v", err)
-		return
+// defaultConfigPath 默认配置文件路径，可通过 HNAI_CONFIG 指定，否则依次查找 config.json、config.yaml、config.yml、config.toml
+func defaultConfigPath() string {
+	if path, ok := os.LookupEnv(config.EnvPrefix + "CONFIG"); ok {
+		return path
 	}
-	// 打印获取到的文章数量
-	fmt.Printf("dev.to 获取到 %d 篇dev.to文章\n", len(stories))
-	blogContent := ""
-	// 为每篇文章生成中文总结
-	for i := range stories {
-		fmt.Printf("%d. %s\n", i, stories[i].Title)
-		if err := aiService.GenerateSummary(&stories[i]); err != nil {
-			log.Printf("dev.to 生成文章总结失败 [%s]: %v", stories[i].Title, err)
-			continue
+	for _, path := range config.ConfigFileNames {
+		if _, err := os.Stat(path); err == nil {
+			return path
 		}
-
-		content := fmt.Sprintf("%s\n\n- 原文: [%s](%s)\n- 作者: %s\n- 点赞数: %d\n- 评论数: %d\n- 发布时间: %s\n\n---\n\n",
-			stories[i].Summary,
-			stories[i].Title,
-			stories[i].URL,
-			stories[i].By,
-			stories[i].Score,
-			stories[i].Descendants,
-			stories[i].Time.Format("2006-01-02 15:04:05"),
-		)
-		blogContent += content
-		// 每次分析间隔3秒，防止api频率限制
-		time.Sleep(3 * time.Second)
 	}
-	if blogContent == "" {
-		fmt.Println("dev.to AI 助手运行错误:", time.Now().Format("2006-01-02 15:04:05"))
-		return
+	return config.ConfigFileNames[0]
+}
+
+// checkConfig 检查配置文件和环境变量，一次列出所有问题，返回进程退出码
+func checkConfig(args []string) int {
+	flags := flag.NewFlagSet("config check", flag.ExitOnError)
+	configPath := flags.String("config", defaultConfigPath(), "配置文件路径，为空时只检查默认配置和环境变量")
+	flags.Parse(args)
+
+	cfg, problems, err := config.Check(*configPath)
+	if err != nil {
+		fmt.Println(err)
+		return 1
 	}
-	today := time.Now().Format("20060102")
-	blogContent = fmt.Sprintf("## DEV 社区中文精选 NO.%s\n\nDev Community 是一个面向全球开发者的技术博客与协作平台，本文是基于 dev.to 的中文日报项目，每天自动抓取 Dev Community 热门文章及评论，通过 AI 生成中文解读与总结，传递科技前沿信息。\n\n![Dev Community 中文精选](https://cdn.wangtwothree.com/imgur/ebLSg8b.png)\n---\n\n%s", today, blogContent)
-	title := fmt.Sprintf("开发者简报 NO.%s：DEV 社区中文解读，全球开发者技术瞭望", today)
-	Pid := fmt.Sprintf("DEV%s", today)
-	// 保存文章和博客内容到数据库
-	if err := storyRepo.SaveStories(blogContent, title, Pid); err != nil {
-		log.Printf("保存数据到数据库失败: %v", err)
+	problems = append(problems, services.CheckConfig(cfg)...)
+	if len(problems) > 0 {
+		fmt.Println(problems)
+		return 1
 	}
-
-	fmt.Println("dev.to AI 助手运行完成于:", time.Now().Format("2006-01-02 15:04:05"))
+	fmt.Println("配置检查通过")
+	return 0
 }
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Digest 一次运行生成的中文精选内容
+type Digest struct {
+	ID        int       `json:"-" gorm:"column:id;primaryKey;autoIncrement"`
+	Source    string    `json:"source" gorm:"column:source;type:varchar(20);index"`
+	Pid       string    `json:"pid" gorm:"column:pid;type:varchar(20);uniqueIndex"`
+	Title     string    `json:"title" gorm:"column:title;type:varchar(200)"`
+	Content   string    `json:"content" gorm:"column:content;type:text"`
+	Cover     string    `json:"cover" gorm:"column:cover;type:varchar(1024)"`
+	Stories   []Story   `json:"stories" gorm:"column:stories;type:text;serializer:json"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at"`
+}
+
+func (*Digest) TableName() string {
+	return "ai_digest"
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// RunReport 一个来源一次运行的报告
+type RunReport struct {
+	ID         int       `json:"-" gorm:"column:id;primaryKey;autoIncrement"`
+	RunID      string    `json:"run_id" gorm:"column:run_id;type:varchar(32);index"`
+	Source     string    `json:"source" gorm:"column:source;type:varchar(20);index"`
+	StartedAt  time.Time `json:"started_at" gorm:"column:started_at"`
+	DurationMs int64     `json:"duration_ms" gorm:"column:duration_ms"`
+	// 各阶段的文章数量
+	Fetched    int `json:"fetched" gorm:"column:fetched"`
+	Filtered   int `json:"filtered" gorm:"column:filtered"`
+	Summarized int `json:"summarized" gorm:"column:summarized"`
+	Failed     int `json:"failed" gorm:"column:failed"`
+	// LLM 使用的 token 数量
+	InputTokens  int `json:"input_tokens" gorm:"column:input_tokens"`
+	OutputTokens int `json:"output_tokens" gorm:"column:output_tokens"`
+	// 本次生成的精选，没有生成时为空
+	Pid string `json:"pid" gorm:"column:pid;type:varchar(20)"`
+	// 是否至少发布到了一个目标
+	Published bool `json:"published" gorm:"column:published"`
+	// 获取内容、生成总结和发布的失败记录
+	Failures []RunFailure `json:"failures" gorm:"column:failures;type:text;serializer:json"`
+	// 导致运行提前结束的错误，如获取文章列表失败或超时
+	Error string `json:"error" gorm:"column:error;type:text"`
+	// 告警原因，没有告警时为空
+	Alert string `json:"alert" gorm:"column:alert;type:varchar(200)"`
+}
+
+func (*RunReport) TableName() string {
+	return "ai_run_report"
+}
+
+// RunFailure 运行中的一次失败
+type RunFailure struct {
+	// 失败的阶段：fetch、summary、publish
+	Stage     string `json:"stage"`
+	StoryID   int    `json:"story_id,omitempty"`
+	Title     string `json:"title,omitempty"`
+	Publisher string `json:"publisher,omitempty"`
+	Reason    string `json:"reason"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// SelectionHistory 已发布精选中的文章，排序时用于判断新颖度
+// 与 ai_digest 分开保存，没有配置 feed 发布目标的来源不会出现在订阅源中
+type SelectionHistory struct {
+	ID        int       `gorm:"column:id;primaryKey;autoIncrement"`
+	Source    string    `gorm:"column:source;type:varchar(20);index"`
+	Pid       string    `gorm:"column:pid;type:varchar(20);uniqueIndex"`
+	Stories   []Story   `gorm:"column:stories;type:text;serializer:json"`
+	CreatedAt time.Time `gorm:"column:created_at;index"`
+}
+
+func (*SelectionHistory) TableName() string {
+	return "ai_selection_history"
+}
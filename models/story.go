@@ -47,14 +47,28 @@ func (*TbPostTag) TableName() string {
 	return "tb_post_tag"
 }
 
+// 文章类型
+const (
+	KindStory = "story"
+	KindRepo  = "repo"
+	KindJob   = "job"
+)
+
 type Story struct {
-	ID          int       `json:"id" gorm:"column:id;primaryKey"`
-	Title       string    `json:"title" gorm:"column:title;type:varchar(100)"`
-	URL         string    `json:"url" gorm:"column:url;type:varchar(1024)"`
-	Score       int       `json:"score" gorm:"column:score"`
-	Time        time.Time `json:"time" gorm:"column:time"`
-	By          string    `json:"by" gorm:"column:by;type:varchar(50)"`
-	Descendants int       `json:"descendants" gorm:"column:descendants"`
-	Content     string    `json:"content" gorm:"column:content;type:text"`
-	Summary     string    `json:"summary" gorm:"column:summary;type:text"`
+	ID    int    `json:"id" gorm:"column:id;primaryKey"`
+	Title string `json:"title" gorm:"column:title;type:varchar(100)"`
+	URL   string `json:"url" gorm:"column:url;type:varchar(1024)"`
+	// 讨论页地址，如 Hacker News 评论页
+	DiscussionURL string    `json:"discussion_url" gorm:"column:discussion_url;type:varchar(1024)"`
+	Score         int       `json:"score" gorm:"column:score"`
+	Time          time.Time `json:"time" gorm:"column:time"`
+	By            string    `json:"by" gorm:"column:by;type:varchar(50)"`
+	Descendants   int       `json:"descendants" gorm:"column:descendants"`
+	Tags          []string  `json:"tags,omitempty" gorm:"column:tags;type:text;serializer:json"`
+	// 文章类型，为空时按普通文章处理
+	Kind string `json:"kind,omitempty" gorm:"column:kind;type:varchar(20)"`
+	// 来源特有的附加信息，如仓库的语言和许可证
+	Meta    map[string]string `json:"meta,omitempty" gorm:"column:meta;type:text;serializer:json"`
+	Content string            `json:"content,omitempty" gorm:"column:content;type:text"`
+	Summary string            `json:"summary" gorm:"column:summary;type:text"`
 }
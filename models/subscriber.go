@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Subscriber 邮件订阅用户
+type Subscriber struct {
+	ID    int    `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	Email string `json:"email" gorm:"column:email;type:varchar(255);uniqueIndex"`
+	Name  string `json:"name" gorm:"column:name;type:varchar(100)"`
+	// 订阅的来源，多个来源用逗号分隔，为空时订阅所有来源
+	Sources string `json:"sources" gorm:"column:sources;type:varchar(255)"`
+	Active  bool   `json:"active" gorm:"column:active;default:true"`
+	// 退订令牌，首次发送邮件时生成
+	Token     string    `json:"-" gorm:"column:token;type:varchar(64);index"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at"`
+}
+
+func (*Subscriber) TableName() string {
+	return "ai_subscriber"
+}
+
+// EmailDelivery 已发送给订阅用户的精选，同一期精选不会重复发送给同一用户
+type EmailDelivery struct {
+	ID           int       `json:"-" gorm:"column:id;primaryKey;autoIncrement"`
+	SubscriberID int       `json:"subscriber_id" gorm:"column:subscriber_id;uniqueIndex:idx_email_delivery"`
+	Pid          string    `json:"pid" gorm:"column:pid;type:varchar(20);uniqueIndex:idx_email_delivery"`
+	CreatedAt    time.Time `json:"created_at" gorm:"column:created_at"`
+}
+
+func (*EmailDelivery) TableName() string {
+	return "ai_email_delivery"
+}
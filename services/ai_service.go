@@ -5,23 +5,28 @@ import (
 	"errors"
 	"fmt"
 	"google.golang.org/api/googleapi"
+	"log/slog"
 	"net/http"
 	"time"
 
 	"github.com/google/generative-ai-go/genai"
 	"github.com/hacker-news-ai/config"
 	"github.com/hacker-news-ai/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/api/option"
 )
 
+// geminiModel 生成总结使用的模型
+const geminiModel = "gemini-2.0-flash-lite"
+
 type AIService struct {
 	config  *config.Config
-	client  *http.Client
 	service *genai.Client
-	hn      *HNService
 }
 
 func NewAIService(cfg *config.Config) (*AIService, error) {
+	// 客户端在服务的整个生命周期内使用，不绑定单次运行的 ctx
 	ctx := context.Background()
 	client, err := genai.NewClient(ctx, option.WithAPIKey(cfg.GeminiAPIKey))
 	if err != nil {
@@ -29,59 +34,56 @@ func NewAIService(cfg *config.Config) (*AIService, error) {
 	}
 
 	return &AIService{
-		config: cfg,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		config:  cfg,
 		service: client,
-		hn:      NewHNService(cfg),
 	}, nil
 }
 
-// GenerateSummary 为文章生成中文总结
-func (s *AIService) GenerateSummary(story *models.Story) error {
-	// 构建提示词
-	prompt := fmt.Sprintf(
-		`你是 Hacker News 中文博客的编辑助理，擅长将 Hacker News 上的文章和评论整理成引人入胜的博客内容。内容受众主要为软件开发者和科技爱好者。
-
-【工作目标】
-- 接收并阅读来自 Hacker News 的文章与评论。
-- 先简明介绍文章的主要话题，再对其要点进行精炼说明。
-- 分析并总结评论区的不同观点，展现多样化视角。
-- 以清晰直接的口吻进行讨论，像与朋友交谈般简洁易懂。
-- 按照逻辑顺序，使用二级标题 (如"## 标题") 与分段正文形式呈现播客的核心精简内容。
-- 所有违反中国大陆法律和政治立场的内容，都跳过。
+// Close 关闭 Gemini 客户端，之后不能再生成总结
+func (s *AIService) Close() error {
+	return s.service.Close()
+}
 
-【输出要求】
-- 直接输出正文，不要返回前言。
-- 直接进入主要内容的总结与讨论：
-  * 第 1-2 句：概括适合搜索引擎收录的文章主题，主题需要使用二级标题。
-  * 第 3-15 句：详细阐述文章的重点内容。
-  * 第 16-25 句：总结和对评论观点的分析，体现多角度探讨。
-- 直接返回 Markdown 格式的正文内容。
-- 换行不要使用\n,使用两个回车。`,
-		story.Title,
-		story.Content,
-	)
+// GenerateSummary 为文章生成中文总结，customPrompt 为来源的自定义提示词，为空时按文章类型选择
+func (s *AIService) GenerateSummary(ctx context.Context, story *models.Story, customPrompt string) (err error) {
+	ctx, span := StartSpan(ctx, "summarize", trace.WithAttributes(attribute.String("model", geminiModel)))
+	defer func() { EndSpan(span, err) }()
+	prompt := buildPrompt(customPrompt, *story)
 
 	retryDelay := 0
 	// 调用Gemini API生成总结
-	ctx := context.Background()
-	model := s.service.GenerativeModel("gemini-2.0-flash-lite")
+	model := s.service.GenerativeModel(geminiModel)
 	model.SetTemperature(0.3)
+	metrics := MetricsFrom(ctx)
 retry:
+	start := time.Now()
 	resp, err := model.GenerateContent(ctx, genai.Text(prompt))
+	var inputTokens, outputTokens int
+	if err == nil && resp.UsageMetadata != nil {
+		inputTokens, outputTokens = int(resp.UsageMetadata.PromptTokenCount), int(resp.UsageMetadata.CandidatesTokenCount)
+	}
+	metrics.LLMRequest(geminiModel, time.Since(start), err, inputTokens, outputTokens)
+	ReportFrom(ctx).Tokens(inputTokens, outputTokens)
+	span.AddEvent("llm_request", trace.WithAttributes(
+		attribute.Int("attempt", retryDelay+1),
+		attribute.Int("input_tokens", inputTokens),
+		attribute.Int("output_tokens", outputTokens),
+	))
 	retryDelay += 1
 	if err != nil {
 		var googleErr *googleapi.Error
 		if errors.As(err, &googleErr) && googleErr.Code == http.StatusTooManyRequests {
+			metrics.RateLimited("gemini")
 			if retryDelay < 3 {
+				metrics.LLMRetry()
 				// 429 错误，等待后重试
-				fmt.Printf("遇到 429 错误，等待 60 秒后重试（第 %d 次）\n", retryDelay)
-				time.Sleep(60 * time.Second)
+				slog.WarnContext(ctx, "遇到 429 错误，等待 60 秒后重试", "attempt", retryDelay)
+				if err := sleepContext(ctx, 60*time.Second); err != nil {
+					return fmt.Errorf("生成总结失败: %v", err)
+				}
 				goto retry
 			} else {
-				fmt.Printf("429 错误已经重试 3 次了，不再重试")
+				slog.ErrorContext(ctx, "429 错误已经重试 3 次，不再重试")
 			}
 		}
 		return fmt.Errorf("生成总结失败: %v", err)
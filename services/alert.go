@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/hacker-news-ai/config"
+	"github.com/hacker-news-ai/models"
+)
+
+// maxAlertFailures 告警文本中列出的失败记录数量上限，完整记录保存在运行报告中
+const maxAlertFailures = 20
+
+// Alerter 在失败次数超过阈值或没有发布精选时，将运行报告发送到 Webhook 和邮件
+type Alerter struct {
+	config config.AlertConfig
+	email  *EmailPublisher
+	client *http.Client
+}
+
+// NewAlerter 创建告警，未配置 alert.webhook_url 和 alert.emails 时只判断告警原因，不发送
+func NewAlerter(cfg *config.Config) *Alerter {
+	return &Alerter{
+		config: cfg.Alert,
+		email:  NewEmailPublisher(cfg.SMTP, nil),
+		client: newHTTPClient(30 * time.Second),
+	}
+}
+
+// Reason 返回运行需要告警的原因，不需要告警时返回空字符串
+func (a *Alerter) Reason(report *models.RunReport) string {
+	if !report.Published {
+		return "没有发布精选"
+	}
+	if len(report.Failures) > a.config.MaxFailures {
+		return fmt.Sprintf("失败 %d 次，超过阈值 %d", len(report.Failures), a.config.MaxFailures)
+	}
+	return ""
+}
+
+// Send 发送告警，单个渠道失败不影响其他渠道
+func (a *Alerter) Send(ctx context.Context, report *models.RunReport) error {
+	subject := fmt.Sprintf("AI 总结助手运行告警：%s", report.Source)
+	text := formatReport(report)
+
+	var errs []error
+	if a.config.WebhookURL != "" {
+		payload := map[string]interface{}{
+			"text":   subject + "\n" + text,
+			"report": report,
+		}
+		if _, err := postJSON(ctx, a.client, a.config.WebhookURL, payload); err != nil {
+			errs = append(errs, fmt.Errorf("发送告警Webhook失败: %v", err))
+		}
+	}
+	if len(a.config.Emails) > 0 {
+		if err := a.sendEmails(ctx, subject, text); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sendEmails 通过一个 SMTP 连接发送告警邮件
+func (a *Alerter) sendEmails(ctx context.Context, subject, text string) error {
+	messages := make([]emailMessage, 0, len(a.config.Emails))
+	for _, address := range a.config.Emails {
+		to, err := mail.ParseAddress(address)
+		if err != nil {
+			return fmt.Errorf("告警邮件地址无效: %v", err)
+		}
+		messages = append(messages, emailMessage{to: *to, subject: subject, text: text, html: "<pre>" + html.EscapeString(text) + "</pre>"})
+	}
+	failed, err := a.email.deliver(ctx, messages, nil)
+	if err != nil {
+		return fmt.Errorf("发送告警邮件失败: %v", err)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d/%d 封告警邮件发送失败", failed, len(messages))
+	}
+	return nil
+}
+
+// formatReport 生成告警使用的运行报告文本
+func formatReport(report *models.RunReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "告警原因：%s\n", report.Alert)
+	fmt.Fprintf(&b, "运行ID：%s\n", report.RunID)
+	fmt.Fprintf(&b, "开始时间：%s\n", report.StartedAt.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "耗时：%.1f 秒\n", float64(report.DurationMs)/1000)
+	fmt.Fprintf(&b, "文章：获取 %d，过滤 %d，总结 %d，失败 %d\n", report.Fetched, report.Filtered, report.Summarized, report.Failed)
+	fmt.Fprintf(&b, "Token：输入 %d，输出 %d\n", report.InputTokens, report.OutputTokens)
+	if report.Pid != "" {
+		published := "已发布"
+		if !report.Published {
+			published = "未发布"
+		}
+		fmt.Fprintf(&b, "精选：%s（%s）\n", report.Pid, published)
+	}
+	if report.Error != "" {
+		fmt.Fprintf(&b, "错误：%s\n", report.Error)
+	}
+	if len(report.Failures) > 0 {
+		b.WriteString("失败记录：\n")
+	}
+	for i, failure := range report.Failures {
+		if i == maxAlertFailures {
+			fmt.Fprintf(&b, "……其余 %d 条见运行报告\n", len(report.Failures)-maxAlertFailures)
+			break
+		}
+		target := failure.Publisher
+		if failure.StoryID != 0 {
+			target = fmt.Sprintf("%s（%d）", failure.Title, failure.StoryID)
+		}
+		fmt.Fprintf(&b, "- [%s] %s：%s\n", failure.Stage, target, failure.Reason)
+	}
+	return b.String()
+}
@@ -0,0 +1,460 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hacker-news-ai/config"
+)
+
+// chatInterval 配置了间隔时使用配置值，否则使用平台默认值
+func chatInterval(intervalMs int, fallback time.Duration) time.Duration {
+	if intervalMs > 0 {
+		return time.Duration(intervalMs) * time.Millisecond
+	}
+	return fallback
+}
+
+// storyMeta 文章的作者、评分和评论数
+func storyMeta(story chatStory) string {
+	return fmt.Sprintf("@%s · %d 分 · %d 评论", story.By, story.Score, story.Comments)
+}
+
+// markdownEscaper 飞书和钉钉 Markdown 中会破坏链接和强调格式的字符
+var markdownEscaper = strings.NewReplacer(
+	"\\", "\\\\", "[", "\\[", "]", "\\]", "*", "\\*", "_", "\\_", "~", "\\~", "`", "\\`",
+)
+
+// markdownURLEscaper Markdown 链接地址中的括号和空格使用百分号编码
+var markdownURLEscaper = strings.NewReplacer("(", "%28", ")", "%29", " ", "%20")
+
+// telegramPlatform Telegram 机器人，使用 MarkdownV2 格式
+type telegramPlatform struct {
+	config config.TelegramConfig
+}
+
+// telegramEscaper MarkdownV2 中需要转义的字符
+var telegramEscaper = strings.NewReplacer(
+	"\\", "\\\\", "_", "\\_", "*", "\\*", "[", "\\[", "]", "\\]", "(", "\\(", ")", "\\)",
+	"~", "\\~", "`", "\\`", ">", "\\>", "#", "\\#", "+", "\\+", "-", "\\-", "=", "\\=",
+	"|", "\\|", "{", "\\{", "}", "\\}", ".", "\\.", "!", "\\!",
+)
+
+// telegramURLEscaper 链接地址中只需转义右括号和反斜杠
+var telegramURLEscaper = strings.NewReplacer("\\", "\\\\", ")", "\\)")
+
+func (p *telegramPlatform) name() string {
+	return "telegram"
+}
+
+func (p *telegramPlatform) interval() time.Duration {
+	// 群组消息限制为每分钟 20 条
+	return chatInterval(p.config.IntervalMs, 3*time.Second)
+}
+
+func (p *telegramPlatform) messages(title, link string, stories []chatStory) []interface{} {
+	// 单条消息最多 4096 个字符，按转义后的长度计算
+	const maxRunes = 4096
+	var blocks []string
+	if title != "" {
+		blocks = append(blocks, fitStory(chatStory{Title: title}, maxRunes, func(title, _ string) string {
+			if link != "" {
+				return fmt.Sprintf("*[%s](%s)*", telegramEscaper.Replace(title), telegramURLEscaper.Replace(link))
+			}
+			return "*" + telegramEscaper.Replace(title) + "*"
+		}))
+	}
+	for _, story := range stories {
+		blocks = append(blocks, fitStory(story, maxRunes, func(title, excerpt string) string {
+			block := fmt.Sprintf("*[%s](%s)*", telegramEscaper.Replace(title), telegramURLEscaper.Replace(story.URL))
+			if excerpt != "" {
+				block += "\n" + telegramEscaper.Replace(excerpt)
+			}
+			block += "\n_" + telegramEscaper.Replace(storyMeta(story)) + "_"
+			if story.DiscussionURL != "" {
+				block += fmt.Sprintf(" [讨论](%s)", telegramURLEscaper.Replace(story.DiscussionURL))
+			}
+			return block
+		}))
+	}
+
+	var messages []interface{}
+	for _, text := range splitBlocks(blocks, "\n\n", maxRunes) {
+		messages = append(messages, map[string]interface{}{
+			"chat_id":                  p.config.ChatID,
+			"text":                     text,
+			"parse_mode":               "MarkdownV2",
+			"disable_web_page_preview": true,
+		})
+	}
+	return messages
+}
+
+func (p *telegramPlatform) send(ctx context.Context, client *http.Client, message interface{}) error {
+	baseURL := p.config.APIBaseURL
+	if baseURL == "" {
+		baseURL = "https://api.telegram.org"
+	}
+	body, err := postJSON(ctx, client, fmt.Sprintf("%s/bot%s/sendMessage", baseURL, p.config.BotToken), message)
+	if err != nil {
+		return err
+	}
+	var result struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("解析Telegram响应失败: %v", err)
+	}
+	if !result.OK {
+		return fmt.Errorf("Telegram返回错误: %s", result.Description)
+	}
+	return nil
+}
+
+// slackPlatform Slack Incoming Webhook，使用 Block Kit 格式
+type slackPlatform struct {
+	config config.ChatWebhookConfig
+}
+
+// slackEscaper Slack mrkdwn 中需要转义的字符
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func (p *slackPlatform) name() string {
+	return "slack"
+}
+
+func (p *slackPlatform) interval() time.Duration {
+	return chatInterval(p.config.IntervalMs, time.Second)
+}
+
+func (p *slackPlatform) messages(title, link string, stories []chatStory) []interface{} {
+	// 单条消息最多 50 个 block，section 文本最多 3000 个字符
+	const maxBlocks = 50
+	var blocks []interface{}
+	if title != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "header",
+			"text": map[string]interface{}{"type": "plain_text", "text": truncateRunes(title, 149)},
+		})
+		if link != "" {
+			blocks = append(blocks, map[string]interface{}{
+				"type": "context",
+				"elements": []interface{}{
+					map[string]interface{}{"type": "mrkdwn", "text": fmt.Sprintf("<%s|查看完整精选>", link)},
+				},
+			})
+		}
+	}
+	for _, story := range stories {
+		text := fitStory(story, 3000, func(title, excerpt string) string {
+			text := fmt.Sprintf("*<%s|%s>*", story.URL, slackEscaper.Replace(title))
+			if excerpt != "" {
+				text += "\n" + slackEscaper.Replace(excerpt)
+			}
+			text += "\n_" + slackEscaper.Replace(storyMeta(story)) + "_"
+			if story.DiscussionURL != "" {
+				text += fmt.Sprintf(" <%s|讨论>", story.DiscussionURL)
+			}
+			return text
+		})
+		blocks = append(blocks,
+			map[string]interface{}{
+				"type": "section",
+				"text": map[string]interface{}{"type": "mrkdwn", "text": text},
+			},
+			map[string]interface{}{"type": "divider"},
+		)
+	}
+
+	fallback := title
+	if fallback == "" && len(stories) > 0 {
+		fallback = stories[0].Title
+	}
+	var messages []interface{}
+	for start := 0; start < len(blocks); start += maxBlocks {
+		end := start + maxBlocks
+		if end > len(blocks) {
+			end = len(blocks)
+		}
+		messages = append(messages, map[string]interface{}{
+			"text":   fallback,
+			"blocks": blocks[start:end],
+		})
+	}
+	return messages
+}
+
+func (p *slackPlatform) send(ctx context.Context, client *http.Client, message interface{}) error {
+	_, err := postJSON(ctx, client, p.config.WebhookURL, message)
+	return err
+}
+
+// discordPlatform Discord Webhook，每篇文章一个 embed
+type discordPlatform struct {
+	config config.ChatWebhookConfig
+}
+
+func (p *discordPlatform) name() string {
+	return "discord"
+}
+
+func (p *discordPlatform) interval() time.Duration {
+	// Webhook 限制为每 2 秒 5 条
+	return chatInterval(p.config.IntervalMs, 500*time.Millisecond)
+}
+
+func (p *discordPlatform) messages(title, link string, stories []chatStory) []interface{} {
+	// 单条消息最多 10 个 embed，所有 embed 合计不超过 6000 个字符
+	const maxEmbeds, maxTotal = 10, 6000
+	content := truncateRunes(title, 1999)
+	if title != "" && link != "" {
+		content = truncateRunes(fmt.Sprintf("**%s**\n<%s>", title, link), 1999)
+	}
+
+	var messages []interface{}
+	var embeds []interface{}
+	total := 0
+	flush := func() {
+		if len(embeds) == 0 {
+			return
+		}
+		messages = append(messages, map[string]interface{}{
+			"content": content,
+			"embeds":  embeds,
+		})
+		// 拆分后的消息不再重复标题
+		content = ""
+		embeds = nil
+		total = 0
+	}
+	for _, story := range stories {
+		description := fitStory(story, 4096, func(_, excerpt string) string {
+			if story.DiscussionURL != "" {
+				return excerpt + fmt.Sprintf("\n\n[讨论](%s)", story.DiscussionURL)
+			}
+			return excerpt
+		})
+		embed := map[string]interface{}{
+			"title":       truncateRunes(story.Title, 255),
+			"url":         story.URL,
+			"description": description,
+			"footer":      map[string]interface{}{"text": storyMeta(story)},
+			"color":       0xff6600,
+		}
+		size := len([]rune(story.Title)) + len([]rune(description)) + len([]rune(storyMeta(story)))
+		if len(embeds) == maxEmbeds || total+size > maxTotal {
+			flush()
+		}
+		embeds = append(embeds, embed)
+		total += size
+	}
+	flush()
+	return messages
+}
+
+func (p *discordPlatform) send(ctx context.Context, client *http.Client, message interface{}) error {
+	_, err := postJSON(ctx, client, p.config.WebhookURL, message)
+	return err
+}
+
+// feishuPlatform 飞书自定义机器人，使用消息卡片格式
+type feishuPlatform struct {
+	config config.ChatWebhookConfig
+}
+
+func (p *feishuPlatform) name() string {
+	return "feishu"
+}
+
+func (p *feishuPlatform) interval() time.Duration {
+	// 自定义机器人限制为每秒 5 条
+	return chatInterval(p.config.IntervalMs, 300*time.Millisecond)
+}
+
+func (p *feishuPlatform) messages(title, link string, stories []chatStory) []interface{} {
+	// 请求体限制为 30KB，按中文 3 字节计算每张卡片最多 8000 个字符，并且最多 10 篇文章
+	const maxStories, maxRunes = 10, 8000
+	if title == "" && len(stories) > 0 {
+		title = stories[0].Title
+	}
+	title = truncateRunes(title, 200)
+
+	var messages []interface{}
+	var elements []interface{}
+	count, total := 0, 0
+	flush := func() {
+		if count == 0 {
+			return
+		}
+		if link != "" {
+			elements = append(elements, map[string]interface{}{
+				"tag": "action",
+				"actions": []interface{}{map[string]interface{}{
+					"tag":  "button",
+					"text": map[string]interface{}{"tag": "plain_text", "content": "查看完整精选"},
+					"url":  link,
+					"type": "primary",
+				}},
+			})
+		}
+		messages = append(messages, map[string]interface{}{
+			"msg_type": "interactive",
+			"card": map[string]interface{}{
+				"header": map[string]interface{}{
+					"title":    map[string]interface{}{"tag": "plain_text", "content": title},
+					"template": "orange",
+				},
+				"elements": elements,
+			},
+		})
+		elements = nil
+		count, total = 0, 0
+	}
+	// 标题、按钮和分隔线预留的长度
+	budget := maxRunes - len([]rune(title)) - len([]rune(link)) - 100
+	for _, story := range stories {
+		text := fitStory(story, budget, func(title, excerpt string) string {
+			text := fmt.Sprintf("**[%s](%s)**", markdownEscaper.Replace(title), markdownURLEscaper.Replace(story.URL))
+			if excerpt != "" {
+				text += "\n" + markdownEscaper.Replace(excerpt)
+			}
+			text += "\n" + markdownEscaper.Replace(storyMeta(story))
+			if story.DiscussionURL != "" {
+				text += fmt.Sprintf(" [讨论](%s)", markdownURLEscaper.Replace(story.DiscussionURL))
+			}
+			return text
+		})
+		size := len([]rune(text))
+		if count == maxStories || total+size > budget {
+			flush()
+		}
+		if count > 0 {
+			elements = append(elements, map[string]interface{}{"tag": "hr"})
+		}
+		elements = append(elements, map[string]interface{}{
+			"tag":  "div",
+			"text": map[string]interface{}{"tag": "lark_md", "content": text},
+		})
+		count++
+		total += size
+	}
+	flush()
+	return messages
+}
+
+func (p *feishuPlatform) send(ctx context.Context, client *http.Client, message interface{}) error {
+	payload := message.(map[string]interface{})
+	if p.config.Secret != "" {
+		// 签名校验：以 timestamp + "\n" + secret 为密钥对空串做 HmacSHA256
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(timestamp+"\n"+p.config.Secret))
+		payload["timestamp"] = timestamp
+		payload["sign"] = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	}
+	body, err := postJSON(ctx, client, p.config.WebhookURL, payload)
+	if err != nil {
+		return err
+	}
+	var result struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("解析飞书响应失败: %v", err)
+	}
+	if result.Code != 0 {
+		return fmt.Errorf("飞书返回错误: %d %s", result.Code, result.Msg)
+	}
+	return nil
+}
+
+// dingTalkPlatform 钉钉自定义机器人，使用 Markdown 消息
+type dingTalkPlatform struct {
+	config config.ChatWebhookConfig
+}
+
+func (p *dingTalkPlatform) name() string {
+	return "dingtalk"
+}
+
+func (p *dingTalkPlatform) interval() time.Duration {
+	// 自定义机器人限制为每分钟 20 条
+	return chatInterval(p.config.IntervalMs, 3*time.Second)
+}
+
+func (p *dingTalkPlatform) messages(title, link string, stories []chatStory) []interface{} {
+	// 消息内容限制为 20000 字节，按中文 3 字节计算留出余量
+	const maxRunes = 6000
+	var blocks []string
+	if title != "" {
+		blocks = append(blocks, fitStory(chatStory{Title: title}, maxRunes, func(title, _ string) string {
+			if link != "" {
+				return fmt.Sprintf("### [%s](%s)", markdownEscaper.Replace(title), markdownURLEscaper.Replace(link))
+			}
+			return "### " + markdownEscaper.Replace(title)
+		}))
+	}
+	for _, story := range stories {
+		blocks = append(blocks, fitStory(story, maxRunes, func(title, excerpt string) string {
+			block := fmt.Sprintf("**[%s](%s)**", markdownEscaper.Replace(title), markdownURLEscaper.Replace(story.URL))
+			if excerpt != "" {
+				block += "\n\n" + markdownEscaper.Replace(excerpt)
+			}
+			block += "\n\n" + markdownEscaper.Replace(storyMeta(story))
+			if story.DiscussionURL != "" {
+				block += fmt.Sprintf(" [讨论](%s)", markdownURLEscaper.Replace(story.DiscussionURL))
+			}
+			return block
+		}))
+	}
+
+	msgTitle := title
+	if msgTitle == "" && len(stories) > 0 {
+		msgTitle = stories[0].Title
+	}
+	var messages []interface{}
+	for _, text := range splitBlocks(blocks, "\n\n---\n\n", maxRunes) {
+		messages = append(messages, map[string]interface{}{
+			"msgtype":  "markdown",
+			"markdown": map[string]interface{}{"title": msgTitle, "text": text},
+		})
+	}
+	return messages
+}
+
+func (p *dingTalkPlatform) send(ctx context.Context, client *http.Client, message interface{}) error {
+	webhookURL := p.config.WebhookURL
+	if p.config.Secret != "" {
+		// 加签：以 secret 为密钥对 timestamp + "\n" + secret 做 HmacSHA256
+		timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		mac := hmac.New(sha256.New, []byte(p.config.Secret))
+		mac.Write([]byte(timestamp + "\n" + p.config.Secret))
+		sign := url.QueryEscape(base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+		webhookURL = fmt.Sprintf("%s&timestamp=%s&sign=%s", webhookURL, timestamp, sign)
+	}
+	body, err := postJSON(ctx, client, webhookURL, message)
+	if err != nil {
+		return err
+	}
+	var result struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("解析钉钉响应失败: %v", err)
+	}
+	if result.ErrCode != 0 {
+		return fmt.Errorf("钉钉返回错误: %d %s", result.ErrCode, result.ErrMsg)
+	}
+	return nil
+}
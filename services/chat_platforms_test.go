@@ -0,0 +1,150 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hacker-news-ai/config"
+)
+
+// validTelegramMarkdown 检查 MarkdownV2 文本没有以未完成的转义结尾，链接文本的方括号成对
+func validTelegramMarkdown(t *testing.T, text string) {
+	t.Helper()
+	trailing := len(text) - len(strings.TrimRight(text, "\\"))
+	if trailing%2 != 0 {
+		t.Errorf("消息以未完成的转义结尾: %q", text[len(text)-20:])
+	}
+	// 去掉转义后方括号应成对出现，链接地址中的左括号不需要转义，不检查圆括号
+	plain := telegramUnescaper.Replace(text)
+	if strings.Count(plain, "[") != strings.Count(plain, "]") {
+		t.Errorf("链接不完整: %q", text)
+	}
+}
+
+// telegramUnescaper 去掉转义字符，用于检查格式
+var telegramUnescaper = strings.NewReplacer(
+	"\\\\", "", "\\[", "", "\\]", "", "\\(", "", "\\)", "",
+)
+
+func TestTelegramMessagesTruncateBeforeEscaping(t *testing.T) {
+	platform := &telegramPlatform{config: config.TelegramConfig{ChatID: "1"}}
+	tests := []struct {
+		name  string
+		story chatStory
+	}{
+		{"escaped excerpt", chatStory{Title: "Go 1.24", URL: "https://go.dev/blog", Excerpt: strings.Repeat("a.b-c(d)!", 1000), By: "rsc"}},
+		{"backslashes", chatStory{Title: "Paths", URL: "https://example.com", Excerpt: strings.Repeat(`C:\dir\`, 1000), By: "x"}},
+		{"long title", chatStory{Title: strings.Repeat("[x]", 2000), URL: "https://example.com/a_(b)", By: "y"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages := platform.messages("今日精选.", "https://example.com/p/1", []chatStory{tt.story})
+			for _, message := range messages {
+				text := message.(map[string]interface{})["text"].(string)
+				if n := len([]rune(text)); n > 4096 {
+					t.Errorf("消息长度 %d 超过 4096", n)
+				}
+				validTelegramMarkdown(t, text)
+			}
+			last := messages[len(messages)-1].(map[string]interface{})["text"].(string)
+			if !strings.Contains(last, "("+telegramURLEscaper.Replace(tt.story.URL)+")") {
+				t.Errorf("缺少文章链接: %q", last)
+			}
+			// 只截掉超出的部分
+			if n := len([]rune(last)); n < 4000 {
+				t.Errorf("文章消息长度 %d，截断过多", n)
+			}
+		})
+	}
+}
+
+func TestMarkdownPlatformsEscapeLinkTitles(t *testing.T) {
+	story := chatStory{Title: "Show HN: [beta] a*b_c", URL: "https://example.com/a (1)", DiscussionURL: "https://news.ycombinator.com/item?id=1", By: "pg"}
+	wantTitle := `[Show HN: \[beta\] a\*b\_c](https://example.com/a%20%281%29)`
+
+	feishu := (&feishuPlatform{}).messages("", "", []chatStory{story})
+	card := feishu[0].(map[string]interface{})["card"].(map[string]interface{})
+	element := card["elements"].([]interface{})[0].(map[string]interface{})
+	if content := element["text"].(map[string]interface{})["content"].(string); !strings.Contains(content, wantTitle) {
+		t.Errorf("飞书链接 = %q, want %q", content, wantTitle)
+	}
+
+	dingtalk := (&dingTalkPlatform{}).messages("精选 [1]", "https://example.com/p", []chatStory{story})
+	text := dingtalk[0].(map[string]interface{})["markdown"].(map[string]interface{})["text"].(string)
+	if !strings.Contains(text, wantTitle) {
+		t.Errorf("钉钉链接 = %q, want %q", text, wantTitle)
+	}
+	if !strings.HasPrefix(text, `### [精选 \[1\]](https://example.com/p)`) {
+		t.Errorf("钉钉标题 = %q", text)
+	}
+}
+
+func TestMarkdownPlatformsEscapeExcerpts(t *testing.T) {
+	story := chatStory{Title: "Go", URL: "https://go.dev", Excerpt: "使用 [link](x) 和 *a_b*", By: "a_b"}
+	wantExcerpt := `使用 \[link\](x) 和 \*a\_b\*`
+
+	feishu := (&feishuPlatform{}).messages("", "", []chatStory{story})
+	card := feishu[0].(map[string]interface{})["card"].(map[string]interface{})
+	element := card["elements"].([]interface{})[0].(map[string]interface{})
+	if content := element["text"].(map[string]interface{})["content"].(string); !strings.Contains(content, wantExcerpt) || !strings.Contains(content, `@a\_b`) {
+		t.Errorf("飞书内容 = %q, want 包含 %q", content, wantExcerpt)
+	}
+
+	dingtalk := (&dingTalkPlatform{}).messages("", "", []chatStory{story})
+	text := dingtalk[0].(map[string]interface{})["markdown"].(map[string]interface{})["text"].(string)
+	if !strings.Contains(text, wantExcerpt) || !strings.Contains(text, `@a\_b`) {
+		t.Errorf("钉钉内容 = %q, want 包含 %q", text, wantExcerpt)
+	}
+}
+
+func TestFeishuMessagesSplitLongDigest(t *testing.T) {
+	var stories []chatStory
+	for i := 0; i < 5; i++ {
+		stories = append(stories, chatStory{Title: fmt.Sprintf("文章%d", i), URL: "https://example.com", Excerpt: strings.Repeat("长", 3000), By: "x"})
+	}
+	// 超长摘要单独截短
+	stories = append(stories, chatStory{Title: "超长", URL: "https://example.com/long", Excerpt: strings.Repeat("a*", 10000), By: "y"})
+
+	messages := (&feishuPlatform{}).messages("今日精选", "https://example.com/p/1", stories)
+	if len(messages) < 3 {
+		t.Fatalf("拆分为 %d 张卡片, want 至少 3 张", len(messages))
+	}
+	count := 0
+	for i, message := range messages {
+		card := message.(map[string]interface{})["card"].(map[string]interface{})
+		size := 0
+		for _, element := range card["elements"].([]interface{}) {
+			element := element.(map[string]interface{})
+			if element["tag"] != "div" {
+				continue
+			}
+			content := element["text"].(map[string]interface{})["content"].(string)
+			size += len([]rune(content))
+			count++
+			// 截短摘要后仍然保留链接和元信息
+			if !strings.HasPrefix(content, "**[") || !strings.HasSuffix(content, " · 0 分 · 0 评论") {
+				t.Errorf("卡片 %d 的文章格式不完整: %q", i, truncateRunes(content, 80))
+			}
+		}
+		if size > 8000 {
+			t.Errorf("卡片 %d 内容长度 %d 超过 8000", i, size)
+		}
+	}
+	if count != len(stories) {
+		t.Errorf("卡片共包含 %d 篇文章, want %d", count, len(stories))
+	}
+}
+
+func TestChatPublisherLimiterPerInstance(t *testing.T) {
+	// 重新加载配置后新建的发布目标使用新的间隔，不与旧实例共用限流器
+	old := NewChatPublisher(&telegramPlatform{config: config.TelegramConfig{IntervalMs: 3000}}, "digest", 0, "")
+	reloaded := NewChatPublisher(&telegramPlatform{config: config.TelegramConfig{IntervalMs: 100}}, "digest", 0, "")
+	if old.limiter == reloaded.limiter {
+		t.Fatal("两个发布目标共用了限流器")
+	}
+	if reloaded.limiter.interval != 100*time.Millisecond {
+		t.Errorf("interval = %v, want 100ms", reloaded.limiter.interval)
+	}
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hacker-news-ai/models"
+)
+
+// chatStory 聊天消息使用的文章数据，各平台基于同一份数据渲染
+type chatStory struct {
+	Title         string
+	URL           string
+	DiscussionURL string
+	Excerpt       string
+	By            string
+	Score         int
+	Comments      int
+}
+
+// chatPlatform 聊天平台的消息格式与发送方式
+type chatPlatform interface {
+	// name 平台名称
+	name() string
+	// interval 两条消息之间的最小间隔
+	interval() time.Duration
+	// messages 将标题和文章渲染为一条或多条消息，超过平台长度限制时拆分
+	messages(title, link string, stories []chatStory) []interface{}
+	// send 发送一条消息
+	send(ctx context.Context, client *http.Client, message interface{}) error
+}
+
+// ChatPublisher 推送精选内容到 Telegram、Slack、Discord、飞书、钉钉等聊天群
+type ChatPublisher struct {
+	platform      chatPlatform
+	mode          string
+	excerptLength int
+	digestURL     string
+	client        *http.Client
+	// 限流器按创建时的配置设置间隔，重新加载配置时随发布目标一起重新创建
+	limiter *rateLimiter
+}
+
+func NewChatPublisher(platform chatPlatform, mode string, excerptLength int, digestURL string) *ChatPublisher {
+	return &ChatPublisher{
+		platform:      platform,
+		mode:          mode,
+		excerptLength: excerptLength,
+		digestURL:     digestURL,
+		client:        newHTTPClient(30 * time.Second),
+		limiter:       &rateLimiter{interval: platform.interval()},
+	}
+}
+
+func (p *ChatPublisher) Name() string {
+	return p.platform.name()
+}
+
+// Publish 按配置以每篇文章一条或每期精选一条的方式推送
+func (p *ChatPublisher) Publish(ctx context.Context, digest *models.Digest) error {
+	var stories []chatStory
+	for _, story := range digest.Stories {
+		stories = append(stories, chatStory{
+			Title:         story.Title,
+			URL:           story.URL,
+			DiscussionURL: story.DiscussionURL,
+			Excerpt:       summaryExcerpt(story.Summary, p.excerptLength),
+			By:            story.By,
+			Score:         story.Score,
+			Comments:      story.Descendants,
+		})
+	}
+
+	var messages []interface{}
+	if p.mode == "story" {
+		for _, story := range stories {
+			messages = append(messages, p.platform.messages("", "", []chatStory{story})...)
+		}
+	} else {
+		link := ""
+		if p.digestURL != "" {
+			link = strings.ReplaceAll(p.digestURL, "{pid}", digest.Pid)
+		}
+		messages = p.platform.messages(digest.Title, link, stories)
+	}
+
+	failed := 0
+	for _, message := range messages {
+		if err := p.limiter.wait(ctx); err != nil {
+			return fmt.Errorf("推送 %s 消息中断: %v", p.platform.name(), err)
+		}
+		if err := p.platform.send(ctx, p.client, message); err != nil {
+			slog.WarnContext(ctx, "推送消息失败", "platform", p.platform.name(), "error", err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d/%d 条消息推送失败", failed, len(messages))
+	}
+	return nil
+}
+
+// rateLimiter 保证两条消息之间至少间隔 interval
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	last     time.Time
+}
+
+// wait 等待到可以发送下一条消息，ctx 取消时返回错误
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if d := time.Until(l.last.Add(l.interval)); d > 0 {
+		if err := sleepContext(ctx, d); err != nil {
+			return err
+		}
+	}
+	l.last = time.Now()
+	return nil
+}
+
+// postJSON 发送 JSON 请求，遇到 429 时按 Retry-After 等待后重试一次
+func postJSON(ctx context.Context, client *http.Client, url string, payload interface{}) ([]byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("序列化消息失败: %v", err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("创建请求失败: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("发送消息失败: %v", err)
+		}
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("读取响应失败: %v", err)
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests {
+			MetricsFrom(ctx).RateLimited(req.URL.Host)
+		}
+		if resp.StatusCode == http.StatusTooManyRequests && attempt == 0 {
+			delay := 5 * time.Second
+			if seconds, err := strconv.ParseFloat(resp.Header.Get("Retry-After"), 64); err == nil {
+				delay = time.Duration(seconds * float64(time.Second))
+			}
+			slog.WarnContext(ctx, "遇到 429 错误，等待后重试", "delay", delay.String())
+			if err := sleepContext(ctx, delay); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return nil, fmt.Errorf("发送消息失败: %s %s", resp.Status, truncateRunes(string(respBody), 200))
+		}
+		return respBody, nil
+	}
+}
+
+// summaryExcerpt 从 AI 总结中提取第一段正文作为摘要
+func summaryExcerpt(summary string, maxRunes int) string {
+	for _, paragraph := range strings.Split(summary, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" || strings.HasPrefix(paragraph, "#") {
+			continue
+		}
+		paragraph = strings.NewReplacer("**", "", "__", "", "`", "").Replace(paragraph)
+		paragraph = strings.Join(strings.Fields(paragraph), " ")
+		return truncateRunes(paragraph, maxRunes)
+	}
+	return ""
+}
+
+// truncateRunes 按字符截断文本
+func truncateRunes(text string, maxRunes int) string {
+	runes := []rune(text)
+	if maxRunes <= 0 || len(runes) <= maxRunes {
+		return text
+	}
+	return string(runes[:maxRunes]) + "…"
+}
+
+// fitStory 渲染文章，超过 maxRunes 个字符时依次截短摘要和标题后重新渲染
+// 截断在转义和拼接链接之前进行，不会留下不完整的转义字符或链接
+func fitStory(story chatStory, maxRunes int, render func(title, excerpt string) string) string {
+	title, excerpt := story.Title, story.Excerpt
+	if text := render(title, excerpt); len([]rune(text)) <= maxRunes {
+		return text
+	}
+	if text, ok := fitRunes(excerpt, maxRunes, func(excerpt string) string { return render(title, excerpt) }); ok {
+		return text
+	}
+	// 去掉摘要后仍然超长时截短标题，链接和元信息本身超长时无法再截短
+	text, _ := fitRunes(title, maxRunes, func(title string) string { return render(title, "") })
+	return text
+}
+
+// fitRunes 按字符截断 text，返回渲染结果不超过 maxRunes 时保留最多内容的结果
+// 转义只会使文本变长，渲染长度随保留的字符数单调增加，可以二分查找
+func fitRunes(text string, maxRunes int, render func(string) string) (string, bool) {
+	runes := []rune(text)
+	result := render("")
+	if len([]rune(result)) > maxRunes {
+		return result, false
+	}
+	lo, hi := 1, len(runes)-1
+	for lo <= hi {
+		keep := (lo + hi) / 2
+		candidate := render(string(runes[:keep]) + "…")
+		if len([]rune(candidate)) <= maxRunes {
+			result = candidate
+			lo = keep + 1
+		} else {
+			hi = keep - 1
+		}
+	}
+	return result, true
+}
+
+// splitBlocks 将多个文本块合并为若干条消息，每条不超过 maxRunes 个字符
+// 文本块需要事先通过 fitStory 控制长度，这里不再截断，避免破坏其中的格式
+func splitBlocks(blocks []string, sep string, maxRunes int) []string {
+	var chunks []string
+	current := ""
+	for _, block := range blocks {
+		if current == "" {
+			current = block
+			continue
+		}
+		if len([]rune(current))+len([]rune(sep))+len([]rune(block)) > maxRunes {
+			chunks = append(chunks, current)
+			current = block
+			continue
+		}
+		current += sep + block
+	}
+	if current != "" {
+		chunks = append(chunks, current)
+	}
+	return chunks
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hacker-news-ai/config"
+)
+
+// CheckConfig 检查配置中的来源、发布目标、过滤规则和文章模板能否正常创建，返回所有问题
+func CheckConfig(cfg *config.Config) []string {
+	var problems []string
+
+	// 按来源配置的各项中引用的来源必须存在
+	referenced := make(map[string]bool)
+	for name := range cfg.Sources {
+		referenced[name] = true
+	}
+	for name := range cfg.Publishers {
+		referenced[name] = true
+	}
+	for name := range cfg.Filters {
+		referenced[name] = true
+	}
+	for name := range cfg.Selection {
+		referenced[name] = true
+	}
+	for name := range cfg.SourceIntervals {
+		referenced[name] = true
+	}
+	for _, name := range cfg.EnabledSources {
+		referenced[name] = true
+	}
+	names := make([]string, 0, len(referenced))
+	for name := range referenced {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, err := NewSource(cfg, name); err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		sourceConfig := cfg.Source(name)
+		if _, err := NewStoryFilter(*sourceConfig.Filters); err != nil {
+			problems = append(problems, fmt.Sprintf("来源 %s: %v", name, err))
+		}
+		if sourceConfig.Template != "" {
+			if _, err := ParseStoryTemplate(sourceConfig.Template); err != nil {
+				problems = append(problems, fmt.Sprintf("来源 %s: %v", name, err))
+			}
+		}
+		if _, err := NewPublishers(cfg, name, nil, nil, nil); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	return problems
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// sleepContext 等待指定时间，ctx 取消时提前返回 ctx 的错误
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// httpGet 发送 GET 请求，ctx 取消或超时时中断请求
+func httpGet(ctx context.Context, client *http.Client, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return client.Do(req)
+}
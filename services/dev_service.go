@@ -1,9 +1,10 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
@@ -20,16 +21,18 @@ type DevService struct {
 func NewDevService(cfg *config.Config) *DevService {
 	return &DevService{
 		config: cfg,
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		client: newHTTPClient(10 * time.Second),
 	}
 }
 
+func (s *DevService) Name() string {
+	return "dev"
+}
+
 // FetchTopStories 获取dev.to热门文章列表
-func (s *DevService) FetchTopStories() ([]models.Story, error) {
+func (s *DevService) FetchTopStories(ctx context.Context) ([]models.Story, error) {
 	// 获取热门文章列表
-	resp, err := s.client.Get(fmt.Sprintf("%s/articles?top=1d&per_page=%d", s.config.DevAPIBaseURL, s.config.TopStoriesLimit))
+	resp, err := httpGet(ctx, s.client, fmt.Sprintf("%s/articles?top=1d&per_page=%d", s.config.DevAPIBaseURL, s.config.Source(s.Name()).Candidates))
 	if err != nil {
 		return nil, fmt.Errorf("获取dev.to热门文章列表失败: %v", err)
 	}
@@ -55,8 +58,11 @@ func (s *DevService) FetchTopStories() ([]models.Story, error) {
 	// 转换为Story模型
 	var stories []models.Story
 	for _, article := range articles {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		// 获取文章的详细内容
-		story, err := s.FetchStory(article.ID)
+		story, err := s.FetchStory(ctx, article.ID)
 		if err != nil {
 			continue
 		}
@@ -67,11 +73,11 @@ func (s *DevService) FetchTopStories() ([]models.Story, error) {
 }
 
 // FetchStory 获取单个文章的详细信息
-func (s *DevService) FetchStory(id int) (models.Story, error) {
+func (s *DevService) FetchStory(ctx context.Context, id int) (models.Story, error) {
 	var story models.Story
 
 	// 获取文章详情
-	resp, err := s.client.Get(fmt.Sprintf("%s/articles/%d", s.config.DevAPIBaseURL, id))
+	resp, err := httpGet(ctx, s.client, fmt.Sprintf("%s/articles/%d", s.config.DevAPIBaseURL, id))
 	if err != nil {
 		return story, fmt.Errorf("获取dev.to文章详情失败: %v", err)
 	}
@@ -96,60 +102,46 @@ func (s *DevService) FetchStory(id int) (models.Story, error) {
 	}
 
 	// 拼接文章的原始内容和评论
-	content, err := s.fetchContent(article.BodyMarkdown, article.ID)
+	content, err := s.fetchContent(ctx, article.BodyMarkdown, article.ID)
 	if err != nil {
 		return story, fmt.Errorf("获取文章内容失败: %v", err)
 	}
 
 	// 转换为Story模型
 	story = models.Story{
-		ID:          article.ID,
-		Title:       article.Title,
-		URL:         article.URL,
-		Score:       article.PositiveReactionsCount,
-		Time:        article.PublishedAt,
-		By:          article.User.Username,
-		Descendants: article.CommentsCount,
-		Content:     content,
+		ID:            article.ID,
+		Title:         article.Title,
+		URL:           article.URL,
+		DiscussionURL: article.URL + "#comments",
+		Score:         article.PositiveReactionsCount,
+		Time:          article.PublishedAt,
+		By:            article.User.Username,
+		Descendants:   article.CommentsCount,
+		Content:       content,
 	}
 
 	return story, nil
 }
 
 // fetchContent 获取文章的原始内容和评论
-func (s *DevService) fetchContent(content string, articleID int) (string, error) {
+func (s *DevService) fetchContent(ctx context.Context, content string, articleID int) (string, error) {
 	// 获取评论内容
-	commentsBody, err := s.fetchComments(articleID)
+	commentsCtx, span := StartSpan(ctx, "comments")
+	commentsBody, err := s.fetchComments(commentsCtx, articleID)
+	EndSpan(span, err)
 	if err != nil {
-		log.Printf("获取评论内容失败: %v", err)
+		slog.WarnContext(ctx, "获取评论内容失败", "error", err)
 		// 评论获取失败不影响返回文章内容
-		return fmt.Sprintf("\n<article>\n%s\n</article>\n", content), nil
-	}
-
-	// 构建返回内容
-	var parts []string
-
-	// 添加文章内容
-	parts = append(parts, fmt.Sprintf("\n<article>\n%s\n</article>\n", content))
-
-	// 添加评论内容
-	parts = append(parts, fmt.Sprintf("\n<comments>\n%s\n</comments>\n", commentsBody))
-
-	// 合并所有内容
-	fullContent := strings.Join(parts, "\n---\n")
-
-	// 限制内容长度，避免token过多
-	if len(fullContent) > 8000 {
-		fullContent = fullContent[:8000]
+		commentsBody = ""
 	}
 
-	return fullContent, nil
+	return buildContent(content, commentsBody), nil
 }
 
 // fetchComments 获取文章的评论内容
-func (s *DevService) fetchComments(articleID int) (string, error) {
+func (s *DevService) fetchComments(ctx context.Context, articleID int) (string, error) {
 	// 获取评论列表
-	resp, err := s.client.Get(fmt.Sprintf("%s/comments?a_id=%d&order=popular", s.config.DevAPIBaseURL, articleID))
+	resp, err := httpGet(ctx, s.client, fmt.Sprintf("%s/comments?a_id=%d&order=popular", s.config.DevAPIBaseURL, articleID))
 	if err != nil {
 		return "", fmt.Errorf("获取dev.to评论列表失败: %v", err)
 	}
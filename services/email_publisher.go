@@ -0,0 +1,408 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hacker-news-ai/config"
+	"github.com/hacker-news-ai/database"
+	"github.com/hacker-news-ai/models"
+)
+
+// emailTemplate 响应式邮件模板，样式使用内联方式以兼容主流邮件客户端
+var emailTemplate = template.Must(template.New("email").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+  body { margin: 0; padding: 0; background: #f4f4f5; }
+  .content img { max-width: 100%; height: auto; }
+  .content a { color: #ff6600; }
+  .content pre { white-space: pre-wrap; word-break: break-all; background: #f4f4f5; padding: 12px; border-radius: 4px; }
+  .content hr { border: none; border-top: 1px solid #e4e4e7; margin: 24px 0; }
+  @media only screen and (max-width: 620px) {
+    .container { width: 100% !important; border-radius: 0 !important; }
+    .content { padding: 16px !important; }
+  }
+</style>
+</head>
+<body style="margin:0;padding:0;background:#f4f4f5;">
+<table role="presentation" width="100%" cellpadding="0" cellspacing="0" style="background:#f4f4f5;">
+  <tr>
+    <td align="center" style="padding:24px 0;">
+      <table role="presentation" class="container" width="600" cellpadding="0" cellspacing="0" style="width:600px;max-width:600px;background:#ffffff;border-radius:8px;">
+        <tr>
+          <td class="content" style="padding:32px;font-family:-apple-system,BlinkMacSystemFont,'PingFang SC','Microsoft YaHei',sans-serif;font-size:15px;line-height:1.7;color:#27272a;">
+            {{.Body}}
+          </td>
+        </tr>
+        <tr>
+          <td style="padding:16px 32px;font-family:sans-serif;font-size:12px;color:#71717a;border-top:1px solid #e4e4e7;">
+            您收到这封邮件是因为订阅了 AI 中文精选，{{if .Unsubscribe}}如需退订请<a href="{{.Unsubscribe}}" style="color:#71717a;">点击这里</a>。{{else}}如需退订请直接回复本邮件。{{end}}
+          </td>
+        </tr>
+      </table>
+    </td>
+  </tr>
+</table>
+</body>
+</html>
+`))
+
+// subscriberStore 订阅用户和邮件发送记录的读写，默认为 SubscriberRepository
+type subscriberStore interface {
+	ListActiveSubscribers(ctx context.Context, source string) ([]models.Subscriber, error)
+	SetToken(ctx context.Context, id int, token string) error
+	ListDelivered(ctx context.Context, pid string) ([]int, error)
+	SaveDelivery(ctx context.Context, delivery *models.EmailDelivery) error
+}
+
+// EmailPublisher 通过 SMTP 将精选内容发送给订阅用户
+type EmailPublisher struct {
+	config config.SMTPConfig
+	store  subscriberStore
+}
+
+func NewEmailPublisher(cfg config.SMTPConfig, subscriberRepo *database.SubscriberRepository) *EmailPublisher {
+	return &EmailPublisher{
+		config: cfg,
+		store:  subscriberRepo,
+	}
+}
+
+func (p *EmailPublisher) Name() string {
+	return "email"
+}
+
+// emailMessage 发送给一个收件人的邮件
+type emailMessage struct {
+	to      mail.Address
+	subject string
+	text    string
+	html    string
+	// 退订地址，非空时添加 List-Unsubscribe 邮件头
+	unsubscribe string
+}
+
+// Publish 渲染邮件并逐个发送给订阅了该来源的用户，单个用户发送失败不影响其他用户
+// 发送成功的用户记录在 ai_email_delivery 表中，重新运行时不会再次收到同一期精选
+func (p *EmailPublisher) Publish(ctx context.Context, digest *models.Digest) error {
+	subscribers, err := p.store.ListActiveSubscribers(ctx, digest.Source)
+	if err != nil {
+		return err
+	}
+	delivered, err := p.store.ListDelivered(ctx, digest.Pid)
+	if err != nil {
+		return err
+	}
+	subscribers = slices.DeleteFunc(subscribers, func(subscriber models.Subscriber) bool {
+		return slices.Contains(delivered, subscriber.ID)
+	})
+	if len(subscribers) == 0 {
+		slog.InfoContext(ctx, "没有需要发送的邮件订阅用户，跳过发送")
+		return nil
+	}
+
+	body, err := markdownToHTML(digest.Content)
+	if err != nil {
+		return err
+	}
+	messages := make([]emailMessage, 0, len(subscribers))
+	for _, subscriber := range subscribers {
+		unsubscribe, err := p.unsubscribeURL(ctx, subscriber)
+		if err != nil {
+			return err
+		}
+		var htmlBody bytes.Buffer
+		if err := emailTemplate.Execute(&htmlBody, map[string]interface{}{
+			"Title":       digest.Title,
+			"Body":        template.HTML(body),
+			"Unsubscribe": unsubscribe,
+		}); err != nil {
+			return fmt.Errorf("渲染邮件模板失败: %v", err)
+		}
+		text := digest.Content
+		if unsubscribe != "" {
+			text += "\n\n退订：" + unsubscribe + "\n"
+		}
+		messages = append(messages, emailMessage{
+			to:          mail.Address{Name: subscriber.Name, Address: subscriber.Email},
+			subject:     digest.Title,
+			text:        text,
+			html:        htmlBody.String(),
+			unsubscribe: unsubscribe,
+		})
+	}
+
+	failed, err := p.deliver(ctx, messages, func(i int) {
+		delivery := &models.EmailDelivery{SubscriberID: subscribers[i].ID, Pid: digest.Pid, CreatedAt: time.Now()}
+		if err := p.store.SaveDelivery(context.WithoutCancel(ctx), delivery); err != nil {
+			slog.WarnContext(ctx, "保存邮件发送记录失败", "email", subscribers[i].Email, "error", err)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d/%d 封邮件发送失败", failed, len(messages))
+	}
+	return nil
+}
+
+// unsubscribeURL 返回订阅用户的退订地址，未配置 smtp.unsubscribe_url 时为空
+// 用户还没有退订令牌时生成并保存
+func (p *EmailPublisher) unsubscribeURL(ctx context.Context, subscriber models.Subscriber) (string, error) {
+	if p.config.UnsubscribeURL == "" {
+		return "", nil
+	}
+	token := subscriber.Token
+	if token == "" {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return "", fmt.Errorf("生成退订令牌失败: %v", err)
+		}
+		token = hex.EncodeToString(b)
+		if err := p.store.SetToken(ctx, subscriber.ID, token); err != nil {
+			return "", err
+		}
+	}
+	return strings.ReplaceAll(p.config.UnsubscribeURL, "{token}", url.QueryEscape(token)), nil
+}
+
+// deliver 通过一个 SMTP 连接逐个发送邮件，单个收件人发送失败不影响其他收件人，返回失败的数量
+// 每封邮件发送成功后以其下标调用 sent，sent 可以为 nil
+func (p *EmailPublisher) deliver(ctx context.Context, messages []emailMessage, sent func(i int)) (int, error) {
+	client, closeClient, err := p.dial(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer closeClient()
+
+	failed, count := 0, 0
+	for i, message := range messages {
+		if ctx.Err() != nil {
+			return failed, fmt.Errorf("发送邮件中断，已发送 %d/%d 封: %v", count, len(messages), ctx.Err())
+		}
+		data, err := p.buildMessage(message)
+		if err != nil {
+			return failed, err
+		}
+		if err := p.send(client, message.to.Address, data); err != nil {
+			slog.WarnContext(ctx, "发送邮件失败", "email", message.to.Address, "error", err)
+			client.Reset()
+			failed++
+			continue
+		}
+		count++
+		if sent != nil {
+			sent(i)
+		}
+	}
+	if ctx.Err() != nil {
+		return failed, fmt.Errorf("发送邮件中断，已发送 %d/%d 封: %v", count, len(messages), ctx.Err())
+	}
+
+	if err := client.Quit(); err != nil {
+		slog.WarnContext(ctx, "关闭SMTP连接失败", "error", err)
+	}
+	return failed, nil
+}
+
+// dial 连接 SMTP 服务器，按配置使用 TLS、STARTTLS 或明文连接
+// 连接的读写截止时间与 ctx 一致，ctx 取消时关闭连接以中断阻塞中的命令；返回的函数用于发送结束后关闭连接
+func (p *EmailPublisher) dial(ctx context.Context) (*smtp.Client, func(), error) {
+	addr := net.JoinHostPort(p.config.Host, strconv.Itoa(p.config.Port))
+	tlsConfig := &tls.Config{
+		ServerName:         p.config.Host,
+		InsecureSkipVerify: p.config.InsecureSkipVerify,
+	}
+
+	var conn net.Conn
+	var err error
+	if p.config.TLS == "tls" {
+		dialer := &tls.Dialer{NetDialer: &net.Dialer{Timeout: 30 * time.Second}, Config: tlsConfig}
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	} else {
+		dialer := &net.Dialer{Timeout: 30 * time.Second}
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("连接SMTP服务器失败: %v", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+
+	client, err := smtp.NewClient(conn, p.config.Host)
+	if err != nil {
+		stop()
+		conn.Close()
+		return nil, nil, fmt.Errorf("连接SMTP服务器失败: %v", err)
+	}
+	closeClient := func() {
+		stop()
+		client.Close()
+	}
+
+	if p.config.TLS == "starttls" {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			closeClient()
+			return nil, nil, fmt.Errorf("STARTTLS 失败: %v", err)
+		}
+	}
+
+	if p.config.Username != "" {
+		auth := smtp.PlainAuth("", p.config.Username, p.config.Password, p.config.Host)
+		if err := client.Auth(auth); err != nil {
+			closeClient()
+			return nil, nil, fmt.Errorf("SMTP认证失败: %v", err)
+		}
+	}
+
+	return client, closeClient, nil
+}
+
+// send 在已建立的连接上发送一封邮件
+func (p *EmailPublisher) send(client *smtp.Client, to string, message []byte) error {
+	if err := client.Mail(p.config.From); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(message); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// buildMessage 生成包含纯文本和 HTML 两个版本的 MIME 邮件
+func (p *EmailPublisher) buildMessage(message emailMessage) ([]byte, error) {
+	boundary, err := randomBoundary()
+	if err != nil {
+		return nil, err
+	}
+	from := mail.Address{Name: p.config.FromName, Address: p.config.From}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", message.to.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", message.subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@%s>\r\n", boundary, p.config.Host)
+	if message.unsubscribe != "" {
+		// RFC 8058 一键退订，邮件客户端向退订地址发送 POST 请求
+		fmt.Fprintf(&buf, "List-Unsubscribe: <%s>\r\n", message.unsubscribe)
+		buf.WriteString("List-Unsubscribe-Post: List-Unsubscribe=One-Click\r\n")
+	}
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+
+	for _, part := range []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=utf-8", message.text},
+		{"text/html; charset=utf-8", message.html},
+	} {
+		fmt.Fprintf(&buf, "--%s\r\n", boundary)
+		fmt.Fprintf(&buf, "Content-Type: %s\r\n", part.contentType)
+		buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		qp := quotedprintable.NewWriter(&buf)
+		if _, err := qp.Write([]byte(strings.ReplaceAll(part.body, "\n", "\r\n"))); err != nil {
+			return nil, fmt.Errorf("编码邮件内容失败: %v", err)
+		}
+		if err := qp.Close(); err != nil {
+			return nil, fmt.Errorf("编码邮件内容失败: %v", err)
+		}
+		buf.WriteString("\r\n")
+	}
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+
+	return buf.Bytes(), nil
+}
+
+// randomBoundary 生成 MIME 分隔符
+func randomBoundary() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成邮件分隔符失败: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// unsubscribePage 退订确认和结果页面
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1"><title>退订 AI 中文精选</title></head>
+<body style="font-family:sans-serif;text-align:center;padding:48px 16px;color:#27272a;">
+{{if .Confirm}}<form method="post"><p>确定不再接收 AI 中文精选邮件吗？</p><button type="submit">确认退订</button></form>{{else}}<p>{{.Message}}</p>{{end}}
+</body>
+</html>
+`))
+
+// unsubscriber 按退订令牌取消订阅，默认为 SubscriberRepository
+type unsubscriber interface {
+	Unsubscribe(ctx context.Context, token string) (bool, error)
+}
+
+// UnsubscribeHandler 处理邮件中的退订链接
+// GET 请求显示确认页面，避免邮件安全扫描访问链接时误退订；POST 请求取消订阅，同时支持 RFC 8058 一键退订
+func UnsubscribeHandler(store unsubscriber) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		render := func(status int, data map[string]interface{}) {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.WriteHeader(status)
+			unsubscribePage.Execute(w, data)
+		}
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			render(http.StatusBadRequest, map[string]interface{}{"Message": "退订链接无效"})
+			return
+		}
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			render(http.StatusOK, map[string]interface{}{"Confirm": true})
+		case http.MethodPost:
+			ok, err := store.Unsubscribe(r.Context(), token)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "取消订阅失败", "error", err)
+				render(http.StatusInternalServerError, map[string]interface{}{"Message": "退订失败，请稍后重试"})
+				return
+			}
+			if !ok {
+				render(http.StatusNotFound, map[string]interface{}{"Message": "退订链接无效"})
+				return
+			}
+			render(http.StatusOK, map[string]interface{}{"Message": "已退订，今后不会再收到 AI 中文精选邮件"})
+		default:
+			w.Header().Set("Allow", "GET, HEAD, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
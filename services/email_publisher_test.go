@@ -0,0 +1,327 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hacker-news-ai/config"
+	"github.com/hacker-news-ai/models"
+)
+
+// fakeSMTPServer 进程内的 SMTP 收件服务，stallOn 为收到该命令后不再响应
+type fakeSMTPServer struct {
+	listener net.Listener
+	stallOn  string
+
+	mu         sync.Mutex
+	recipients []string
+	messages   []string
+}
+
+func newFakeSMTPServer(t *testing.T, stallOn string) *fakeSMTPServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("监听失败: %v", err)
+	}
+	s := &fakeSMTPServer{listener: listener, stallOn: stallOn}
+	t.Cleanup(func() { listener.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *fakeSMTPServer) config() config.SMTPConfig {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	n, _ := strconv.Atoi(port)
+	return config.SMTPConfig{Host: host, Port: n, From: "digest@example.com", TLS: "none"}
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		if s.stallOn != "" && command == s.stallOn {
+			// 不再响应，直到客户端关闭连接
+			buf := make([]byte, 1)
+			for {
+				if _, err := conn.Read(buf); err != nil {
+					return
+				}
+			}
+		}
+		switch command {
+		case "EHLO", "HELO":
+			reply("250 localhost")
+		case "MAIL", "RSET", "NOOP":
+			reply("250 OK")
+		case "RCPT":
+			s.mu.Lock()
+			s.recipients = append(s.recipients, line)
+			s.mu.Unlock()
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				dataLine, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, data.String())
+			s.mu.Unlock()
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func TestEmailDeliver(t *testing.T) {
+	server := newFakeSMTPServer(t, "")
+	publisher := NewEmailPublisher(server.config(), nil)
+	messages := []emailMessage{
+		{to: mail.Address{Name: "读者", Address: "reader@example.com"}, subject: "今日精选", text: "正文", html: "<p>正文</p>"},
+		{to: mail.Address{Address: "other@example.com"}, subject: "今日精选", text: "正文", html: "<p>正文</p>"},
+	}
+
+	failed, err := publisher.deliver(context.Background(), messages, nil)
+	if err != nil {
+		t.Fatalf("发送失败: %v", err)
+	}
+	if failed != 0 {
+		t.Fatalf("failed = %d, want 0", failed)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if len(server.messages) != 2 {
+		t.Fatalf("收到 %d 封邮件, want 2", len(server.messages))
+	}
+	if !strings.Contains(server.recipients[0], "reader@example.com") || !strings.Contains(server.recipients[1], "other@example.com") {
+		t.Errorf("收件人 = %v", server.recipients)
+	}
+	for _, header := range []string{"From: ", "To: ", "Subject: =?UTF-8?b?", "multipart/alternative", "text/plain", "text/html"} {
+		if !strings.Contains(server.messages[0], header) {
+			t.Errorf("邮件缺少 %q:\n%s", header, server.messages[0])
+		}
+	}
+	// 告警等没有退订地址的邮件不添加退订邮件头
+	if strings.Contains(server.messages[0], "List-Unsubscribe") {
+		t.Errorf("邮件包含退订邮件头:\n%s", server.messages[0])
+	}
+}
+
+// fakeSubscriberStore 内存中的订阅用户和发送记录
+type fakeSubscriberStore struct {
+	subscribers []models.Subscriber
+	deliveries  []models.EmailDelivery
+}
+
+func (s *fakeSubscriberStore) ListActiveSubscribers(ctx context.Context, source string) ([]models.Subscriber, error) {
+	var subscribers []models.Subscriber
+	for _, subscriber := range s.subscribers {
+		if subscriber.Active {
+			subscribers = append(subscribers, subscriber)
+		}
+	}
+	return subscribers, nil
+}
+
+func (s *fakeSubscriberStore) SetToken(ctx context.Context, id int, token string) error {
+	for i := range s.subscribers {
+		if s.subscribers[i].ID == id {
+			s.subscribers[i].Token = token
+		}
+	}
+	return nil
+}
+
+func (s *fakeSubscriberStore) ListDelivered(ctx context.Context, pid string) ([]int, error) {
+	var ids []int
+	for _, delivery := range s.deliveries {
+		if delivery.Pid == pid {
+			ids = append(ids, delivery.SubscriberID)
+		}
+	}
+	return ids, nil
+}
+
+func (s *fakeSubscriberStore) SaveDelivery(ctx context.Context, delivery *models.EmailDelivery) error {
+	s.deliveries = append(s.deliveries, *delivery)
+	return nil
+}
+
+func (s *fakeSubscriberStore) Unsubscribe(ctx context.Context, token string) (bool, error) {
+	for i := range s.subscribers {
+		if s.subscribers[i].Token == token {
+			s.subscribers[i].Active = false
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestEmailPublishSkipsDelivered(t *testing.T) {
+	server := newFakeSMTPServer(t, "")
+	cfg := server.config()
+	cfg.UnsubscribeURL = "https://example.com/unsubscribe?token={token}"
+	store := &fakeSubscriberStore{
+		subscribers: []models.Subscriber{
+			{ID: 1, Email: "sent@example.com", Active: true, Token: "t1"},
+			{ID: 2, Email: "new@example.com", Active: true},
+		},
+		// 上次运行已发送给第一个用户
+		deliveries: []models.EmailDelivery{{SubscriberID: 1, Pid: "HN20250315"}},
+	}
+	publisher := NewEmailPublisher(cfg, nil)
+	publisher.store = store
+	digest := &models.Digest{Source: "hn", Pid: "HN20250315", Title: "今日精选", Content: "## 今日精选\n\n正文"}
+
+	for i := 0; i < 2; i++ {
+		if err := publisher.Publish(context.Background(), digest); err != nil {
+			t.Fatalf("第 %d 次发布失败: %v", i+1, err)
+		}
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	// 两次发布都只发送给未收到的用户，第二次全部跳过
+	if len(server.messages) != 1 || !strings.Contains(server.recipients[0], "new@example.com") {
+		t.Fatalf("收件人 = %v, want [new@example.com]", server.recipients)
+	}
+	if len(store.deliveries) != 2 || store.deliveries[1].SubscriberID != 2 {
+		t.Errorf("发送记录 = %+v", store.deliveries)
+	}
+
+	token := store.subscribers[1].Token
+	if token == "" {
+		t.Fatal("没有生成退订令牌")
+	}
+	unsubscribe := "https://example.com/unsubscribe?token=" + token
+	message := server.messages[0]
+	for _, header := range []string{
+		"List-Unsubscribe: <" + unsubscribe + ">",
+		"List-Unsubscribe-Post: List-Unsubscribe=One-Click",
+	} {
+		if !strings.Contains(message, header) {
+			t.Errorf("邮件缺少 %q:\n%s", header, message)
+		}
+	}
+}
+
+func TestUnsubscribeHandler(t *testing.T) {
+	store := &fakeSubscriberStore{subscribers: []models.Subscriber{{ID: 1, Email: "reader@example.com", Active: true, Token: "abc"}}}
+	handler := UnsubscribeHandler(store)
+	tests := []struct {
+		name   string
+		method string
+		token  string
+		status int
+		active bool
+	}{
+		// 打开链接只显示确认页面
+		{"confirm page", http.MethodGet, "abc", http.StatusOK, true},
+		{"missing token", http.MethodPost, "", http.StatusBadRequest, true},
+		{"unknown token", http.MethodPost, "xyz", http.StatusNotFound, true},
+		{"unsupported method", http.MethodDelete, "abc", http.StatusMethodNotAllowed, true},
+		// 确认或邮件客户端一键退订
+		{"unsubscribe", http.MethodPost, "abc", http.StatusOK, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/unsubscribe?token="+tt.token, strings.NewReader("List-Unsubscribe=One-Click"))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if active := store.subscribers[0].Active; active != tt.active {
+				t.Errorf("active = %v, want %v", active, tt.active)
+			}
+		})
+	}
+}
+
+func TestEmailDeliverStalledServer(t *testing.T) {
+	tests := []struct {
+		name    string
+		stallOn string
+		// cancel 为 true 时取消 ctx，否则使用截止时间
+		cancel bool
+	}{
+		{"deadline during MAIL", "MAIL", false},
+		{"deadline during DATA", "DATA", false},
+		{"cancel during RCPT", "RCPT", true},
+		{"cancel during EHLO", "EHLO", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeSMTPServer(t, tt.stallOn)
+			publisher := NewEmailPublisher(server.config(), nil)
+
+			var ctx context.Context
+			var cancel context.CancelFunc
+			if tt.cancel {
+				ctx, cancel = context.WithCancel(context.Background())
+				time.AfterFunc(200*time.Millisecond, cancel)
+			} else {
+				ctx, cancel = context.WithTimeout(context.Background(), 200*time.Millisecond)
+			}
+			defer cancel()
+
+			start := time.Now()
+			done := make(chan error, 1)
+			go func() {
+				_, err := publisher.deliver(ctx, []emailMessage{{to: mail.Address{Address: "reader@example.com"}, subject: "今日精选", text: "正文", html: "<p>正文</p>"}}, nil)
+				done <- err
+			}()
+			select {
+			case err := <-done:
+				if err == nil {
+					t.Fatal("服务器无响应时发送成功")
+				}
+				if elapsed := time.Since(start); elapsed > 2*time.Second {
+					t.Errorf("发送耗时 %v，未按 ctx 结束", elapsed)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("服务器无响应时发送未按 ctx 结束")
+			}
+		})
+	}
+}
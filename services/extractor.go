@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxContentLength 发送给 AI 的内容长度上限，避免token过多
+const maxContentLength = 8000
+
+// ContentExtractor 通过 r.jina.ai 提取网页正文
+type ContentExtractor struct {
+	client *http.Client
+}
+
+func NewContentExtractor() *ContentExtractor {
+	return &ContentExtractor{
+		client: newHTTPClient(10 * time.Second),
+	}
+}
+
+// Extract 获取网页正文的 Markdown 内容
+func (e *ContentExtractor) Extract(ctx context.Context, url string) (content string, err error) {
+	ctx, span := StartSpan(ctx, "extract", trace.WithAttributes(attribute.String("url", url)))
+	defer func() { EndSpan(span, err) }()
+
+	// 设置请求头
+	headers := make(http.Header)
+	headers.Set("X-Retain-Images", "none")
+
+	// 获取文章内容
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://r.jina.ai/"+url, nil)
+	if err != nil {
+		return "", fmt.Errorf("创建文章请求失败: %v", err)
+	}
+	req.Header = headers
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("获取文章内容失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("获取文章失败: %s %s", resp.Status, url)
+	}
+
+	articleBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("读取文章内容失败: %v", err)
+	}
+
+	return string(articleBody), nil
+}
+
+// buildContent 拼接文章正文和评论，评论为空时只包含正文
+func buildContent(article, comments string) string {
+	// 构建返回内容
+	var parts []string
+
+	// 添加文章内容
+	parts = append(parts, fmt.Sprintf("\n<article>\n%s\n</article>\n", article))
+
+	// 添加评论内容
+	if comments != "" {
+		parts = append(parts, fmt.Sprintf("\n<comments>\n%s\n</comments>\n", comments))
+	}
+
+	// 合并所有内容
+	content := strings.Join(parts, "\n---\n")
+
+	// 限制内容长度，避免token过多
+	if len(content) > maxContentLength {
+		content = content[:maxContentLength]
+	}
+
+	return content
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+
+	"github.com/hacker-news-ai/database"
+	"github.com/hacker-news-ai/models"
+)
+
+// FeedPublisher 保存精选内容供订阅使用，并在配置了输出目录时重新生成订阅文件
+type FeedPublisher struct {
+	feedService *FeedService
+	digestRepo  *database.DigestRepository
+}
+
+func NewFeedPublisher(feedService *FeedService, digestRepo *database.DigestRepository) *FeedPublisher {
+	return &FeedPublisher{
+		feedService: feedService,
+		digestRepo:  digestRepo,
+	}
+}
+
+func (p *FeedPublisher) Name() string {
+	return "feed"
+}
+
+// Publish 保存精选内容，并写出该来源和全部来源的订阅文件
+func (p *FeedPublisher) Publish(ctx context.Context, digest *models.Digest) error {
+	if err := p.digestRepo.SaveDigest(ctx, digest); err != nil {
+		return err
+	}
+	if p.feedService.config.Dir == "" {
+		return nil
+	}
+
+	for source, filter := range map[string]string{digest.Source: digest.Source, "all": ""} {
+		digests, err := p.digestRepo.ListDigests(ctx, filter, p.feedService.config.Limit)
+		if err != nil {
+			return err
+		}
+		if err := p.feedService.WriteFiles(source, digests); err != nil {
+			return err
+		}
+	}
+	return nil
+}
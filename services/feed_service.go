@@ -0,0 +1,375 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hacker-news-ai/config"
+	"github.com/hacker-news-ai/database"
+	"github.com/hacker-news-ai/models"
+	"github.com/yuin/goldmark"
+)
+
+// 支持的订阅格式及对应的文件后缀
+var feedFormats = map[string]string{
+	"rss":  ".rss.xml",
+	"atom": ".atom.xml",
+	"json": ".feed.json",
+}
+
+// FeedService 根据已保存的精选内容生成 RSS 2.0、Atom 和 JSON Feed
+type FeedService struct {
+	config config.FeedConfig
+}
+
+// feedItem 订阅条目，精选或单篇文章统一转换为该结构
+type feedItem struct {
+	id        string
+	title     string
+	link      string
+	author    string
+	content   string
+	published time.Time
+}
+
+func NewFeedService(cfg config.FeedConfig) *FeedService {
+	return &FeedService{
+		config: cfg,
+	}
+}
+
+// Render 生成指定格式的订阅内容，source 为 all 时包含所有来源
+func (s *FeedService) Render(format, source string, digests []models.Digest) ([]byte, string, error) {
+	items, err := s.items(digests)
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch format {
+	case "rss":
+		data, err := s.rss(source, items)
+		return data, "application/rss+xml; charset=utf-8", err
+	case "atom":
+		data, err := s.atom(source, items)
+		return data, "application/atom+xml; charset=utf-8", err
+	case "json":
+		data, err := s.jsonFeed(source, items)
+		return data, "application/feed+json; charset=utf-8", err
+	default:
+		return nil, "", fmt.Errorf("不支持的订阅格式: %s", format)
+	}
+}
+
+// WriteFiles 将所有格式的订阅内容写入输出目录
+func (s *FeedService) WriteFiles(source string, digests []models.Digest) error {
+	if err := os.MkdirAll(s.config.Dir, 0755); err != nil {
+		return fmt.Errorf("创建订阅目录失败: %v", err)
+	}
+	for format, ext := range feedFormats {
+		data, _, err := s.Render(format, source, digests)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(s.config.Dir, source+ext), data, 0644); err != nil {
+			return fmt.Errorf("写入订阅文件失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// Handler 提供 /feeds/{来源}.rss.xml 等订阅地址，内容实时从数据库生成
+func (s *FeedService) Handler(digestRepo *database.DigestRepository) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/feeds/")
+		for format, ext := range feedFormats {
+			source, ok := strings.CutSuffix(name, ext)
+			if !ok || source == "" {
+				continue
+			}
+			filter := source
+			if source == "all" {
+				filter = ""
+			}
+			digests, err := digestRepo.ListDigests(r.Context(), filter, s.config.Limit)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "生成订阅失败", "path", r.URL.Path, "error", err)
+				http.Error(w, "生成订阅失败", http.StatusInternalServerError)
+				return
+			}
+			data, contentType, err := s.Render(format, source, digests)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "生成订阅失败", "path", r.URL.Path, "error", err)
+				http.Error(w, "生成订阅失败", http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", contentType)
+			w.Write(data)
+			return
+		}
+		http.NotFound(w, r)
+	})
+}
+
+// items 将精选内容转换为订阅条目，开启 story_items 时每篇文章单独作为一个条目
+func (s *FeedService) items(digests []models.Digest) ([]feedItem, error) {
+	var items []feedItem
+	for _, digest := range digests {
+		if !s.config.StoryItems {
+			content, err := markdownToHTML(digest.Content)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, feedItem{
+				id:        digestGUID(digest.Pid),
+				title:     digest.Title,
+				link:      s.digestURL(digest.Pid),
+				content:   content,
+				published: digest.CreatedAt,
+			})
+			continue
+		}
+
+		for _, story := range digest.Stories {
+			content, err := markdownToHTML(story.Summary)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, feedItem{
+				id:        storyGUID(digest.Pid, story.ID),
+				title:     story.Title,
+				link:      story.URL,
+				author:    story.By,
+				content:   content,
+				published: digest.CreatedAt,
+			})
+		}
+	}
+	return items, nil
+}
+
+// digestURL 精选内容的访问地址
+func (s *FeedService) digestURL(pid string) string {
+	if s.config.ItemURL == "" {
+		return s.config.SiteURL
+	}
+	return strings.ReplaceAll(s.config.ItemURL, "{pid}", pid)
+}
+
+// feedTitle 订阅标题，单一来源时附加来源名称
+func (s *FeedService) feedTitle(source string) string {
+	if source == "" || source == "all" {
+		return s.config.Title
+	}
+	return fmt.Sprintf("%s · %s", s.config.Title, source)
+}
+
+// feedURL 订阅自身的地址
+func (s *FeedService) feedURL(format, source string) string {
+	return strings.TrimSuffix(s.config.SiteURL, "/") + "/feeds/" + source + feedFormats[format]
+}
+
+// digestGUID 由 Pid 生成稳定的条目 ID
+func digestGUID(pid string) string {
+	return "urn:hacker-news-ai:digest:" + pid
+}
+
+// storyGUID 由精选 Pid 和文章 ID 生成稳定的条目 ID
+// 同一篇文章可能出现在不同来源或不同日期的精选中，每期精选中的条目各自独立
+func storyGUID(pid string, storyID int) string {
+	return fmt.Sprintf("urn:hacker-news-ai:story:%s:%d", pid, storyID)
+}
+
+// markdownToHTML 将 Markdown 转换为 HTML
+func markdownToHTML(markdown string) (string, error) {
+	var buf bytes.Buffer
+	if err := goldmark.Convert([]byte(markdown), &buf); err != nil {
+		return "", fmt.Errorf("转换Markdown失败: %v", err)
+	}
+	return buf.String(), nil
+}
+
+// rss 生成 RSS 2.0 文档
+func (s *FeedService) rss(source string, items []feedItem) ([]byte, error) {
+	type guid struct {
+		IsPermaLink string `xml:"isPermaLink,attr"`
+		Value       string `xml:",chardata"`
+	}
+	type rssItem struct {
+		Title       string `xml:"title"`
+		Link        string `xml:"link,omitempty"`
+		GUID        guid   `xml:"guid"`
+		Author      string `xml:"dc:creator,omitempty"`
+		Description string `xml:"description"`
+		PubDate     string `xml:"pubDate"`
+	}
+	type channel struct {
+		Title         string    `xml:"title"`
+		Link          string    `xml:"link"`
+		Description   string    `xml:"description"`
+		Language      string    `xml:"language"`
+		LastBuildDate string    `xml:"lastBuildDate"`
+		Items         []rssItem `xml:"item"`
+	}
+	type rss struct {
+		XMLName xml.Name `xml:"rss"`
+		Version string   `xml:"version,attr"`
+		DC      string   `xml:"xmlns:dc,attr"`
+		Channel channel  `xml:"channel"`
+	}
+
+	doc := rss{
+		Version: "2.0",
+		DC:      "http://purl.org/dc/elements/1.1/",
+		Channel: channel{
+			Title:         s.feedTitle(source),
+			Link:          s.config.SiteURL,
+			Description:   s.config.Description,
+			Language:      "zh-cn",
+			LastBuildDate: time.Now().Format(time.RFC1123Z),
+		},
+	}
+	for _, item := range items {
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:       item.title,
+			Link:        item.link,
+			GUID:        guid{IsPermaLink: "false", Value: item.id},
+			Author:      item.author,
+			Description: item.content,
+			PubDate:     item.published.Format(time.RFC1123Z),
+		})
+	}
+
+	return marshalXML(doc)
+}
+
+// atom 生成 Atom 文档
+func (s *FeedService) atom(source string, items []feedItem) ([]byte, error) {
+	type link struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr,omitempty"`
+	}
+	type author struct {
+		Name string `xml:"name"`
+	}
+	type content struct {
+		Type  string `xml:"type,attr"`
+		Value string `xml:",chardata"`
+	}
+	type entry struct {
+		ID        string  `xml:"id"`
+		Title     string  `xml:"title"`
+		Link      *link   `xml:"link,omitempty"`
+		Author    *author `xml:"author,omitempty"`
+		Updated   string  `xml:"updated"`
+		Published string  `xml:"published"`
+		Content   content `xml:"content"`
+	}
+	type feed struct {
+		XMLName  xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		ID       string   `xml:"id"`
+		Title    string   `xml:"title"`
+		Subtitle string   `xml:"subtitle,omitempty"`
+		Links    []link   `xml:"link"`
+		Updated  string   `xml:"updated"`
+		Entries  []entry  `xml:"entry"`
+	}
+
+	doc := feed{
+		ID:       "urn:hacker-news-ai:feed:" + source,
+		Title:    s.feedTitle(source),
+		Subtitle: s.config.Description,
+		Links: []link{
+			{Href: s.config.SiteURL},
+			{Href: s.feedURL("atom", source), Rel: "self"},
+		},
+		Updated: time.Now().Format(time.RFC3339),
+	}
+	for _, item := range items {
+		e := entry{
+			ID:        item.id,
+			Title:     item.title,
+			Updated:   item.published.Format(time.RFC3339),
+			Published: item.published.Format(time.RFC3339),
+			Content:   content{Type: "html", Value: item.content},
+		}
+		if item.link != "" {
+			e.Link = &link{Href: item.link}
+		}
+		if item.author != "" {
+			e.Author = &author{Name: item.author}
+		}
+		doc.Entries = append(doc.Entries, e)
+	}
+
+	return marshalXML(doc)
+}
+
+// jsonFeed 生成 JSON Feed 1.1 文档
+func (s *FeedService) jsonFeed(source string, items []feedItem) ([]byte, error) {
+	type author struct {
+		Name string `json:"name"`
+	}
+	type item struct {
+		ID            string   `json:"id"`
+		URL           string   `json:"url,omitempty"`
+		Title         string   `json:"title"`
+		ContentHTML   string   `json:"content_html"`
+		DatePublished string   `json:"date_published"`
+		Authors       []author `json:"authors,omitempty"`
+	}
+	type feed struct {
+		Version     string `json:"version"`
+		Title       string `json:"title"`
+		HomePageURL string `json:"home_page_url,omitempty"`
+		FeedURL     string `json:"feed_url,omitempty"`
+		Description string `json:"description,omitempty"`
+		Language    string `json:"language"`
+		Items       []item `json:"items"`
+	}
+
+	doc := feed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       s.feedTitle(source),
+		HomePageURL: s.config.SiteURL,
+		FeedURL:     s.feedURL("json", source),
+		Description: s.config.Description,
+		Language:    "zh-CN",
+		Items:       []item{},
+	}
+	for _, it := range items {
+		entry := item{
+			ID:            it.id,
+			URL:           it.link,
+			Title:         it.title,
+			ContentHTML:   it.content,
+			DatePublished: it.published.Format(time.RFC3339),
+		}
+		if it.author != "" {
+			entry.Authors = []author{{Name: it.author}}
+		}
+		doc.Items = append(doc.Items, entry)
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("生成JSON Feed失败: %v", err)
+	}
+	return data, nil
+}
+
+// marshalXML 序列化 XML 并添加文档头
+func marshalXML(doc interface{}) ([]byte, error) {
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("生成订阅XML失败: %v", err)
+	}
+	return append([]byte(xml.Header), data...), nil
+}
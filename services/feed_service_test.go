@@ -0,0 +1,172 @@
+package services
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hacker-news-ai/config"
+	"github.com/hacker-news-ai/models"
+)
+
+// feedDigests 两期包含同一篇文章的精选，按时间倒序
+func feedDigests() []models.Digest {
+	story := models.Story{ID: 42, Title: "Go 1.24 发布", URL: "https://go.dev/blog/go1.24", By: "rsc", Summary: "**新特性**"}
+	return []models.Digest{
+		{Source: "hn", Pid: "HN20250315", Title: "精选 0315", Content: "## 今日\n\n正文", Stories: []models.Story{story}, CreatedAt: time.Date(2025, 3, 15, 8, 0, 0, 0, time.UTC)},
+		{Source: "hn", Pid: "HN20250314", Title: "精选 0314", Content: "正文", Stories: []models.Story{story}, CreatedAt: time.Date(2025, 3, 14, 8, 0, 0, 0, time.UTC)},
+	}
+}
+
+// renderedEntry 各格式解析出的条目，用于统一比较
+type renderedEntry struct {
+	ID, Title, Link, Content, Published string
+}
+
+// parseFeed 解析订阅内容中的条目
+func parseFeed(t *testing.T, format string, data []byte) []renderedEntry {
+	t.Helper()
+	var entries []renderedEntry
+	switch format {
+	case "rss":
+		var doc struct {
+			Items []struct {
+				Title       string `xml:"title"`
+				Link        string `xml:"link"`
+				GUID        string `xml:"guid"`
+				Description string `xml:"description"`
+				PubDate     string `xml:"pubDate"`
+			} `xml:"channel>item"`
+		}
+		if err := xml.Unmarshal(data, &doc); err != nil {
+			t.Fatalf("解析 RSS 失败: %v", err)
+		}
+		for _, item := range doc.Items {
+			published, err := time.Parse(time.RFC1123Z, item.PubDate)
+			if err != nil {
+				t.Fatalf("pubDate 格式错误: %v", err)
+			}
+			entries = append(entries, renderedEntry{item.GUID, item.Title, item.Link, item.Description, published.Format(time.RFC3339)})
+		}
+	case "atom":
+		var doc struct {
+			Entries []struct {
+				ID    string `xml:"id"`
+				Title string `xml:"title"`
+				Link  struct {
+					Href string `xml:"href,attr"`
+				} `xml:"link"`
+				Content   string `xml:"content"`
+				Published string `xml:"published"`
+			} `xml:"entry"`
+		}
+		if err := xml.Unmarshal(data, &doc); err != nil {
+			t.Fatalf("解析 Atom 失败: %v", err)
+		}
+		for _, entry := range doc.Entries {
+			entries = append(entries, renderedEntry{entry.ID, entry.Title, entry.Link.Href, entry.Content, entry.Published})
+		}
+	case "json":
+		var doc struct {
+			Version string `json:"version"`
+			Items   []struct {
+				ID            string `json:"id"`
+				URL           string `json:"url"`
+				Title         string `json:"title"`
+				ContentHTML   string `json:"content_html"`
+				DatePublished string `json:"date_published"`
+			} `json:"items"`
+		}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			t.Fatalf("解析 JSON Feed 失败: %v", err)
+		}
+		if doc.Version != "https://jsonfeed.org/version/1.1" {
+			t.Errorf("version = %q", doc.Version)
+		}
+		for _, item := range doc.Items {
+			entries = append(entries, renderedEntry{item.ID, item.Title, item.URL, item.ContentHTML, item.DatePublished})
+		}
+	}
+	return entries
+}
+
+func TestFeedRender(t *testing.T) {
+	cfg := config.FeedConfig{Title: "AI 中文精选", SiteURL: "https://example.com", ItemURL: "https://example.com/p/{pid}"}
+	tests := []struct {
+		name       string
+		storyItems bool
+		want       []renderedEntry
+	}{
+		{"digest items", false, []renderedEntry{
+			{"urn:hacker-news-ai:digest:HN20250315", "精选 0315", "https://example.com/p/HN20250315", "<h2>今日</h2>\n<p>正文</p>\n", "2025-03-15T08:00:00Z"},
+			{"urn:hacker-news-ai:digest:HN20250314", "精选 0314", "https://example.com/p/HN20250314", "<p>正文</p>\n", "2025-03-14T08:00:00Z"},
+		}},
+		// 同一篇文章出现在两期精选中，条目 ID 不重复
+		{"story items", true, []renderedEntry{
+			{"urn:hacker-news-ai:story:HN20250315:42", "Go 1.24 发布", "https://go.dev/blog/go1.24", "<p><strong>新特性</strong></p>\n", "2025-03-15T08:00:00Z"},
+			{"urn:hacker-news-ai:story:HN20250314:42", "Go 1.24 发布", "https://go.dev/blog/go1.24", "<p><strong>新特性</strong></p>\n", "2025-03-14T08:00:00Z"},
+		}},
+	}
+	for _, tt := range tests {
+		for format := range feedFormats {
+			t.Run(tt.name+"/"+format, func(t *testing.T) {
+				cfg.StoryItems = tt.storyItems
+				data, _, err := NewFeedService(cfg).Render(format, "hn", feedDigests())
+				if err != nil {
+					t.Fatalf("生成订阅失败: %v", err)
+				}
+				if got := parseFeed(t, format, data); !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("条目 =\n%+v\nwant\n%+v", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestFeedGUIDStable(t *testing.T) {
+	for _, storyItems := range []bool{false, true} {
+		service := NewFeedService(config.FeedConfig{Title: "AI 中文精选", StoryItems: storyItems})
+		all, _, err := service.Render("rss", "hn", feedDigests())
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 新一期精选发布后，旧条目的 ID 保持不变
+		older, _, err := service.Render("rss", "hn", feedDigests()[1:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		allEntries, olderEntries := parseFeed(t, "rss", all), parseFeed(t, "rss", older)
+		if len(allEntries) != 2 || len(olderEntries) != 1 || allEntries[1].ID != olderEntries[0].ID {
+			t.Errorf("story_items=%v 条目 ID 变化: %+v, %+v", storyItems, allEntries, olderEntries)
+		}
+	}
+}
+
+func TestBuildDigestStripsStoryContent(t *testing.T) {
+	stories := []models.Story{{ID: 1, Title: "Go", URL: "https://go.dev", Content: "原文全文", Summary: "总结"}}
+	digest := DigestSpecs["hn"].BuildDigest("hn", stories, time.Date(2025, 3, 15, 8, 0, 0, 0, time.UTC))
+
+	// 保存到 ai_digest 和推送到 Webhook 的内容不包含原文
+	data, err := json.Marshal(digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload struct {
+		Stories []map[string]interface{} `json:"stories"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if len(payload.Stories) != 1 || payload.Stories[0]["summary"] != "总结" {
+		t.Fatalf("stories = %v", payload.Stories)
+	}
+	if _, ok := payload.Stories[0]["content"]; ok {
+		t.Errorf("精选包含原文: %s", data)
+	}
+	// 不修改调用方的文章
+	if stories[0].Content != "原文全文" {
+		t.Errorf("原文被清空")
+	}
+}
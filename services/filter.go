@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hacker-news-ai/config"
+	"github.com/hacker-news-ai/models"
+)
+
+// StoryFilter 按规则过滤抓取到的文章
+type StoryFilter struct {
+	config   config.FilterConfig
+	patterns []*regexp.Regexp
+}
+
+// NewStoryFilter 创建文章过滤器，预先编译标题正则
+func NewStoryFilter(cfg config.FilterConfig) (*StoryFilter, error) {
+	filter := &StoryFilter{config: cfg}
+	for _, pattern := range cfg.DenyPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("过滤规则正则表达式错误 [%s]: %v", pattern, err)
+		}
+		filter.patterns = append(filter.patterns, re)
+	}
+	return filter, nil
+}
+
+// Apply 返回通过过滤的文章，被排除的文章及命中的规则记录到日志
+func (f *StoryFilter) Apply(ctx context.Context, stories []models.Story, now time.Time) []models.Story {
+	var kept []models.Story
+	for _, story := range stories {
+		if rule := f.Match(story, now); rule != "" {
+			slog.InfoContext(ctx, "过滤文章", LogStoryID, story.ID, "title", story.Title, "rule", rule)
+			continue
+		}
+		kept = append(kept, story)
+	}
+	return kept
+}
+
+// Match 返回文章命中的排除规则，未命中时返回空字符串
+func (f *StoryFilter) Match(story models.Story, now time.Time) string {
+	host := storyHost(story.URL)
+	if len(f.config.AllowDomains) > 0 && !matchDomain(host, f.config.AllowDomains) {
+		return fmt.Sprintf("域名 %s 不在 allow_domains 中", host)
+	}
+	for _, domain := range f.config.DenyDomains {
+		if matchDomain(host, []string{domain}) {
+			return fmt.Sprintf("deny_domains: %s", domain)
+		}
+	}
+
+	title := strings.ToLower(story.Title)
+	for _, keyword := range f.config.DenyKeywords {
+		if strings.Contains(title, strings.ToLower(keyword)) {
+			return fmt.Sprintf("deny_keywords: %s", keyword)
+		}
+	}
+	for _, re := range f.patterns {
+		if re.MatchString(story.Title) {
+			return fmt.Sprintf("deny_patterns: %s", re)
+		}
+	}
+
+	if story.Score < f.config.MinScore {
+		return fmt.Sprintf("min_score: %d < %d", story.Score, f.config.MinScore)
+	}
+	if story.Descendants < f.config.MinComments {
+		return fmt.Sprintf("min_comments: %d < %d", story.Descendants, f.config.MinComments)
+	}
+	if f.config.MaxAgeHours > 0 && now.Sub(story.Time) > time.Duration(f.config.MaxAgeHours)*time.Hour {
+		return fmt.Sprintf("max_age_hours: 发布于 %s", story.Time.Format("2006-01-02 15:04:05"))
+	}
+
+	if len(f.config.Kinds) > 0 {
+		kind := story.Kind
+		if kind == "" {
+			kind = models.KindStory
+		}
+		allowed := false
+		for _, k := range f.config.Kinds {
+			if k == kind {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Sprintf("kinds: 类型 %s 不在允许列表中", kind)
+		}
+	}
+	return ""
+}
+
+// storyHost 返回文章链接的域名，去掉 www. 前缀
+func storyHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// matchDomain 判断域名是否为列表中的域名或其子域名
+func matchDomain(host string, domains []string) bool {
+	for _, domain := range domains {
+		domain = strings.TrimPrefix(strings.ToLower(domain), "www.")
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
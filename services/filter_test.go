@@ -0,0 +1,70 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hacker-news-ai/config"
+	"github.com/hacker-news-ai/models"
+)
+
+func TestStoryFilterMatch(t *testing.T) {
+	now := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	story := func(url, title string) models.Story {
+		return models.Story{URL: url, Title: title, Score: 100, Descendants: 20, Time: now.Add(-time.Hour)}
+	}
+	tests := []struct {
+		name   string
+		config config.FilterConfig
+		story  models.Story
+		want   string
+	}{
+		{"no rules", config.FilterConfig{}, story("https://example.com/a", "Hello"), ""},
+
+		// 域名
+		{"allow domain", config.FilterConfig{AllowDomains: []string{"github.com"}}, story("https://github.com/golang/go", "Go"), ""},
+		{"allow subdomain", config.FilterConfig{AllowDomains: []string{"github.com"}}, story("https://gist.github.com/x", "Gist"), ""},
+		{"allow strips www", config.FilterConfig{AllowDomains: []string{"www.github.com"}}, story("https://WWW.GitHub.com/x", "Go"), ""},
+		{"not allowed", config.FilterConfig{AllowDomains: []string{"github.com"}}, story("https://example.com/a", "Hello"), "域名 example.com 不在 allow_domains 中"},
+		{"suffix is not subdomain", config.FilterConfig{AllowDomains: []string{"hub.com"}}, story("https://github.com/x", "Go"), "域名 github.com 不在 allow_domains 中"},
+		{"deny domain", config.FilterConfig{DenyDomains: []string{"medium.com"}}, story("https://www.medium.com/p/1", "Post"), "deny_domains: medium.com"},
+		{"deny subdomain", config.FilterConfig{DenyDomains: []string{"medium.com"}}, story("https://blog.medium.com/p/1", "Post"), "deny_domains: medium.com"},
+		{"deny other domain", config.FilterConfig{DenyDomains: []string{"medium.com"}}, story("https://notmedium.com/p/1", "Post"), ""},
+
+		// 标题
+		{"keyword case insensitive", config.FilterConfig{DenyKeywords: []string{"Crypto"}}, story("https://example.com", "New CRYPTO exchange"), "deny_keywords: Crypto"},
+		{"keyword not found", config.FilterConfig{DenyKeywords: []string{"crypto"}}, story("https://example.com", "Rust 1.85"), ""},
+		{"pattern", config.FilterConfig{DenyPatterns: []string{`^(Ask|Tell) HN:`}}, story("https://example.com", "Ask HN: Who is hiring?"), "deny_patterns: ^(Ask|Tell) HN:"},
+		{"pattern is case sensitive", config.FilterConfig{DenyPatterns: []string{`^Ask HN:`}}, story("https://example.com", "ask hn: lowercase"), ""},
+
+		// 数值和类型
+		{"min score", config.FilterConfig{MinScore: 101}, story("https://example.com", "Hello"), "min_score: 100 < 101"},
+		{"min score equal", config.FilterConfig{MinScore: 100}, story("https://example.com", "Hello"), ""},
+		{"min comments", config.FilterConfig{MinComments: 21}, story("https://example.com", "Hello"), "min_comments: 20 < 21"},
+		{"max age", config.FilterConfig{MaxAgeHours: 1}, models.Story{Time: now.Add(-61 * time.Minute)}, "max_age_hours: 发布于 2025-03-15 10:59:00"},
+		{"max age equal", config.FilterConfig{MaxAgeHours: 1}, models.Story{Time: now.Add(-time.Hour)}, ""},
+		{"kinds default story", config.FilterConfig{Kinds: []string{"story"}}, story("https://example.com", "Hello"), ""},
+		{"kinds excluded", config.FilterConfig{Kinds: []string{"story"}}, models.Story{Kind: models.KindJob, Time: now}, "kinds: 类型 job 不在允许列表中"},
+		{"kinds allowed", config.FilterConfig{Kinds: []string{"story", "repo"}}, models.Story{Kind: models.KindRepo, Time: now}, ""},
+
+		// 按规则顺序返回第一条命中的规则
+		{"first rule wins", config.FilterConfig{DenyDomains: []string{"example.com"}, DenyKeywords: []string{"hello"}, MinScore: 1000}, story("https://example.com", "Hello"), "deny_domains: example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := NewStoryFilter(tt.config)
+			if err != nil {
+				t.Fatalf("创建过滤器失败: %v", err)
+			}
+			if got := filter.Match(tt.story, now); got != tt.want {
+				t.Errorf("Match() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewStoryFilterInvalidPattern(t *testing.T) {
+	if _, err := NewStoryFilter(config.FilterConfig{DenyPatterns: []string{"("}}); err == nil {
+		t.Error("无效的正则表达式应返回错误")
+	}
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"context"
+
+	"github.com/hacker-news-ai/database"
+	"github.com/hacker-news-ai/models"
+)
+
+// ForumPublisher 发布到 go_simple_forum 论坛数据库
+type ForumPublisher struct {
+	storyRepo *database.StoryRepository
+}
+
+func NewForumPublisher(storyRepo *database.StoryRepository) *ForumPublisher {
+	return &ForumPublisher{
+		storyRepo: storyRepo,
+	}
+}
+
+func (p *ForumPublisher) Name() string {
+	return "forum"
+}
+
+// Publish 保存精选内容到论坛文章表
+func (p *ForumPublisher) Publish(ctx context.Context, digest *models.Digest) error {
+	return p.storyRepo.SaveStories(ctx, digest.Content, digest.Title, digest.Pid)
+}
@@ -0,0 +1,289 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hacker-news-ai/config"
+	"github.com/hacker-news-ai/models"
+)
+
+var (
+	// trendingRepoPattern 趋势页中每个仓库的链接
+	trendingRepoPattern = regexp.MustCompile(`<h2[^>]*>\s*<a[^>]*href="/([^/"]+/[^/"]+)"`)
+	// trendingStarsPattern 趋势页中仓库在统计周期内新增的 Star
+	trendingStarsPattern = regexp.MustCompile(`([\d,]+)\s+stars\s+(today|this week|this month)`)
+)
+
+// trendingRepo 趋势仓库及其新增 Star
+type trendingRepo struct {
+	fullName    string
+	starsGained int
+}
+
+type GitHubService struct {
+	config *config.Config
+	client *http.Client
+}
+
+func NewGitHubService(cfg *config.Config) *GitHubService {
+	return &GitHubService{
+		config: cfg,
+		client: newHTTPClient(10 * time.Second),
+	}
+}
+
+func (s *GitHubService) Name() string {
+	return "github"
+}
+
+// FetchTopStories 获取趋势仓库列表，趋势页按新增 Star 排序，搜索模式按 Star 总数排序
+func (s *GitHubService) FetchTopStories(ctx context.Context) ([]models.Story, error) {
+	var repos []trendingRepo
+	var err error
+	if s.config.GitHub.Mode == "search" {
+		repos, err = s.searchRepos(ctx)
+	} else {
+		repos, err = s.trendingRepos(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 限制获取的仓库数量
+	if len(repos) > s.config.Source(s.Name()).Candidates {
+		repos = repos[:s.config.Source(s.Name()).Candidates]
+	}
+
+	// 获取每个仓库的详细信息和 README
+	var stories []models.Story
+	for _, repo := range repos {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		story, err := s.FetchStory(ctx, repo.fullName, repo.starsGained)
+		if err != nil {
+			slog.WarnContext(ctx, "获取GitHub仓库失败", "repo", repo.fullName, "error", err)
+			continue
+		}
+		stories = append(stories, story)
+	}
+
+	return stories, nil
+}
+
+// trendingRepos 解析 github.com/trending 页面，多个语言的结果合并去重
+func (s *GitHubService) trendingRepos(ctx context.Context) ([]trendingRepo, error) {
+	languages := s.config.GitHub.Languages
+	if len(languages) == 0 {
+		// 不限语言
+		languages = []string{""}
+	}
+
+	seen := make(map[string]bool)
+	var repos []trendingRepo
+	for _, language := range languages {
+		pageURL := fmt.Sprintf("%s/trending/%s?since=%s", s.config.GitHub.WebBaseURL, url.PathEscape(language), s.config.GitHub.Since)
+		body, err := s.get(ctx, pageURL, "text/html")
+		if err != nil {
+			slog.WarnContext(ctx, "获取GitHub趋势页失败", "language", language, "error", err)
+			continue
+		}
+
+		// 按仓库分段解析，避免新增 Star 与仓库错位
+		for _, article := range strings.Split(string(body), "<article")[1:] {
+			match := trendingRepoPattern.FindStringSubmatch(article)
+			if match == nil || seen[match[1]] {
+				continue
+			}
+			seen[match[1]] = true
+			repo := trendingRepo{fullName: match[1]}
+			if stars := trendingStarsPattern.FindStringSubmatch(article); stars != nil {
+				repo.starsGained, _ = strconv.Atoi(strings.ReplaceAll(stars[1], ",", ""))
+			}
+			repos = append(repos, repo)
+		}
+	}
+	if len(repos) == 0 {
+		return nil, fmt.Errorf("未能从GitHub趋势页解析到仓库")
+	}
+
+	sort.SliceStable(repos, func(i, j int) bool {
+		return repos[i].starsGained > repos[j].starsGained
+	})
+	return repos, nil
+}
+
+// githubSinceDays 统计周期对应的搜索天数，取值在配置校验时检查
+var githubSinceDays = map[string]int{
+	"daily":   1,
+	"weekly":  7,
+	"monthly": 30,
+}
+
+// searchQuery 生成搜索近期创建的仓库的查询条件
+func searchQuery(since string, languages []string, now time.Time) string {
+	query := fmt.Sprintf("created:>%s", now.AddDate(0, 0, -githubSinceDays[since]).Format("2006-01-02"))
+	for _, language := range languages {
+		query += " language:" + language
+	}
+	return query
+}
+
+// searchRepos 通过搜索接口查找近期创建且 Star 增长最快的仓库
+func (s *GitHubService) searchRepos(ctx context.Context) ([]trendingRepo, error) {
+	params := url.Values{}
+	params.Set("q", searchQuery(s.config.GitHub.Since, s.config.GitHub.Languages, time.Now()))
+	params.Set("sort", "stars")
+	params.Set("order", "desc")
+	params.Set("per_page", strconv.Itoa(s.config.Source(s.Name()).Candidates))
+	body, err := s.get(ctx, fmt.Sprintf("%s/search/repositories?%s", s.config.GitHub.APIBaseURL, params.Encode()), "application/vnd.github+json")
+	if err != nil {
+		return nil, fmt.Errorf("搜索GitHub仓库失败: %v", err)
+	}
+
+	var result struct {
+		Items []struct {
+			FullName        string `json:"full_name"`
+			StargazersCount int    `json:"stargazers_count"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("解析GitHub搜索结果失败: %v", err)
+	}
+
+	// 搜索结果已按 Star 总数排序，无法得知统计周期内新增的数量
+	var repos []trendingRepo
+	for _, item := range result.Items {
+		repos = append(repos, trendingRepo{fullName: item.FullName})
+	}
+	return repos, nil
+}
+
+// FetchStory 获取仓库的元数据和 README
+func (s *GitHubService) FetchStory(ctx context.Context, fullName string, starsGained int) (models.Story, error) {
+	var story models.Story
+
+	body, err := s.get(ctx, fmt.Sprintf("%s/repos/%s", s.config.GitHub.APIBaseURL, fullName), "application/vnd.github+json")
+	if err != nil {
+		return story, fmt.Errorf("获取仓库信息失败: %v", err)
+	}
+
+	var repo struct {
+		ID              int       `json:"id"`
+		FullName        string    `json:"full_name"`
+		HTMLURL         string    `json:"html_url"`
+		Description     string    `json:"description"`
+		Homepage        string    `json:"homepage"`
+		Language        string    `json:"language"`
+		StargazersCount int       `json:"stargazers_count"`
+		ForksCount      int       `json:"forks_count"`
+		OpenIssuesCount int       `json:"open_issues_count"`
+		Topics          []string  `json:"topics"`
+		CreatedAt       time.Time `json:"created_at"`
+		PushedAt        time.Time `json:"pushed_at"`
+		Owner           struct {
+			Login string `json:"login"`
+		} `json:"owner"`
+		License *struct {
+			SpdxID string `json:"spdx_id"`
+		} `json:"license"`
+	}
+	if err := json.Unmarshal(body, &repo); err != nil {
+		return story, fmt.Errorf("解析仓库信息失败: %v", err)
+	}
+
+	// README 获取失败时仍可根据元数据生成介绍
+	readme, err := s.get(ctx, fmt.Sprintf("%s/repos/%s/readme", s.config.GitHub.APIBaseURL, fullName), "application/vnd.github.raw")
+	if err != nil {
+		slog.WarnContext(ctx, "获取README失败", "repo", fullName, "error", err)
+	}
+
+	license := "未声明"
+	if repo.License != nil && repo.License.SpdxID != "" && repo.License.SpdxID != "NOASSERTION" {
+		license = repo.License.SpdxID
+	}
+	language := repo.Language
+	if language == "" {
+		language = "未知"
+	}
+
+	meta := map[string]string{
+		"language": language,
+		"license":  license,
+		"forks":    strconv.Itoa(repo.ForksCount),
+	}
+	stars := strconv.Itoa(repo.StargazersCount)
+	// 搜索模式只有 Star 总数，不显示新增数量
+	if s.config.GitHub.Mode != "search" {
+		period := map[string]string{"daily": "今日", "weekly": "本周", "monthly": "本月"}[s.config.GitHub.Since]
+		meta["stars_gained"] = fmt.Sprintf("%s +%d", period, starsGained)
+		stars += "（" + meta["stars_gained"] + "）"
+	}
+	info := fmt.Sprintf("仓库：%s\n简介：%s\n主页：%s\nStar：%s\nFork：%d\n语言：%s\n许可证：%s\n话题：%s\n创建时间：%s\n最近提交：%s",
+		repo.FullName,
+		repo.Description,
+		repo.Homepage,
+		stars,
+		repo.ForksCount,
+		language,
+		license,
+		strings.Join(repo.Topics, ", "),
+		repo.CreatedAt.Format("2006-01-02"),
+		repo.PushedAt.Format("2006-01-02"),
+	)
+
+	title := repo.FullName
+	if repo.Description != "" {
+		title = fmt.Sprintf("%s：%s", repo.FullName, repo.Description)
+	}
+
+	story = models.Story{
+		ID:          repo.ID,
+		Title:       title,
+		URL:         repo.HTMLURL,
+		Score:       repo.StargazersCount,
+		Time:        repo.CreatedAt,
+		By:          repo.Owner.Login,
+		Descendants: repo.OpenIssuesCount,
+		Tags:        repo.Topics,
+		Kind:        models.KindRepo,
+		Meta:        meta,
+		Content:     buildContent(info+"\n\n"+string(readme), ""),
+	}
+
+	return story, nil
+}
+
+// get 请求 GitHub，配置了 token 时携带认证信息以提高频率限制
+func (s *GitHubService) get(ctx context.Context, rawURL, accept string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	if s.config.GitHub.Token != "" && strings.HasPrefix(rawURL, s.config.GitHub.APIBaseURL) {
+		req.Header.Set("Authorization", "Bearer "+s.config.GitHub.Token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("请求失败: %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
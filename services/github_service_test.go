@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hacker-news-ai/config"
+)
+
+func TestSearchQuery(t *testing.T) {
+	now := time.Date(2025, 3, 15, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		since     string
+		languages []string
+		want      string
+	}{
+		{"daily", nil, "created:>2025-03-14"},
+		{"weekly", nil, "created:>2025-03-08"},
+		{"monthly", nil, "created:>2025-02-13"},
+		{"weekly", []string{"go", "rust"}, "created:>2025-03-08 language:go language:rust"},
+	}
+	for _, tt := range tests {
+		if got := searchQuery(tt.since, tt.languages, now); got != tt.want {
+			t.Errorf("searchQuery(%q, %v) = %q, want %q", tt.since, tt.languages, got, tt.want)
+		}
+	}
+}
+
+func TestGitHubStarsLabel(t *testing.T) {
+	baseURL := newFixtureServer(t, map[string]string{"/repos/octo/fast": "testdata/github_repo.json"})
+	tests := []struct {
+		mode string
+		want string
+	}{
+		{"trending", "- Star: 1200（今日 +300）\n"},
+		// 搜索模式只有 Star 总数，不能显示为今日新增
+		{"search", "- Star: 1200\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			cfg := &config.Config{GitHub: config.GitHubConfig{APIBaseURL: baseURL, Mode: tt.mode, Since: "daily"}}
+			story, err := NewGitHubService(cfg).FetchStory(context.Background(), "octo/fast", 300)
+			if err != nil {
+				t.Fatalf("获取仓库失败: %v", err)
+			}
+			if got := DigestSpecs["github"].FormatStory(story); !strings.Contains(got, tt.want) {
+				t.Errorf("精选格式 =\n%s\nwant 包含 %q", got, tt.want)
+			}
+			if tt.mode == "search" && strings.Contains(story.Content, "今日") {
+				t.Errorf("提示词包含新增 Star: %q", story.Content)
+			}
+		})
+	}
+}
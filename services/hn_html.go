@@ -0,0 +1,119 @@
+package services
+
+import (
+	"bytes"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// hnHTMLToMarkdown 将 HN 帖子和评论的 HTML 转换为 Markdown
+// HN 只使用少量标签：<p> 分段、<a> 链接、<i> 斜体、<pre><code> 代码块，引用以 > 开头
+func hnHTMLToMarkdown(text string) string {
+	var b bytes.Buffer
+	linkStart, preStart := -1, -1
+	var href string
+
+	// paragraph 开始新段落，避免重复空行
+	paragraph := func() {
+		if b.Len() > 0 && !bytes.HasSuffix(b.Bytes(), []byte("\n\n")) {
+			b.WriteString(strings.Repeat("\n", 2-trailingNewlines(b.Bytes())))
+		}
+	}
+
+	z := html.NewTokenizer(strings.NewReader(text))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		token := z.Token()
+		switch tt {
+		case html.TextToken:
+			data := token.Data
+			if preStart < 0 {
+				if bytes.HasSuffix(b.Bytes(), []byte("\n\n")) {
+					data = strings.TrimLeft(data, "\n")
+				}
+				// HN 的引用为以 > 开头的段落
+				atLineStart := b.Len() == 0 || bytes.HasSuffix(b.Bytes(), []byte("\n"))
+				if atLineStart && strings.HasPrefix(data, ">") {
+					data = "> " + strings.TrimLeft(data[1:], " ")
+				}
+			}
+			b.WriteString(data)
+		case html.StartTagToken, html.SelfClosingTagToken:
+			switch token.DataAtom {
+			case atom.P:
+				paragraph()
+			case atom.Br:
+				b.WriteString("\n")
+			case atom.I, atom.Em:
+				b.WriteString("*")
+			case atom.A:
+				for _, attr := range token.Attr {
+					if attr.Key == "href" {
+						href = attr.Val
+					}
+				}
+				linkStart = b.Len()
+			case atom.Pre:
+				paragraph()
+				preStart = b.Len()
+			}
+		case html.EndTagToken:
+			switch token.DataAtom {
+			case atom.I, atom.Em:
+				b.WriteString("*")
+			case atom.A:
+				if linkStart < 0 {
+					continue
+				}
+				label := b.String()[linkStart:]
+				b.Truncate(linkStart)
+				b.WriteString(formatLink(label, href))
+				linkStart = -1
+			case atom.Pre:
+				if preStart < 0 {
+					continue
+				}
+				code := dedentCode(b.String()[preStart:])
+				b.Truncate(preStart)
+				b.WriteString("```\n" + code + "\n```\n\n")
+				preStart = -1
+			}
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// formatLink 生成 Markdown 链接，HN 会把过长的链接文字截断为 URL 前缀加 ...，此时直接使用 URL
+func formatLink(label, href string) string {
+	if href == "" {
+		return label
+	}
+	trimmed := strings.TrimSuffix(label, "...")
+	if label == href || strings.HasPrefix(href, trimmed) && trimmed != "" {
+		return href
+	}
+	return "[" + label + "](" + href + ")"
+}
+
+// dedentCode 去掉 HN 代码块每行开头用于标记代码的两个空格
+func dedentCode(code string) string {
+	lines := strings.Split(strings.Trim(code, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(line, "  ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// trailingNewlines 返回末尾换行符数量
+func trailingNewlines(data []byte) int {
+	n := 0
+	for n < len(data) && data[len(data)-1-n] == '\n' {
+		n++
+	}
+	return n
+}
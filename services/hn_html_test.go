@@ -0,0 +1,31 @@
+package services
+
+import "testing"
+
+func TestHNHTMLToMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"empty", "", ""},
+		{"paragraphs", "First paragraph.<p>Second paragraph.<p>Third.", "First paragraph.\n\nSecond paragraph.\n\nThird."},
+		{"line break", "line one<br>line two", "line one\nline two"},
+		{"link", `See <a href="https://example.com/page" rel="nofollow">the docs</a> for more.`, "See [the docs](https://example.com/page) for more."},
+		{"link text is url", `<a href="https://example.com" rel="nofollow">https://example.com</a>`, "https://example.com"},
+		// HN 会把过长的链接文字截断为前缀加 ...
+		{"truncated link text", `<a href="https://example.com/a/very/long/path" rel="nofollow">https://example.com/a/very/...</a>`, "https://example.com/a/very/long/path"},
+		{"quote", "&gt; quoted text<p>My reply.", "> quoted text\n\nMy reply."},
+		{"italics", "I <i>really</i> mean it.", "I *really* mean it."},
+		{"code block", "Code:<p><pre><code>  func main() {\n      fmt.Println(&quot;hi&quot;)\n  }\n</code></pre>After.", "Code:\n\n```\nfunc main() {\n    fmt.Println(\"hi\")\n}\n```\n\nAfter."},
+		{"nested tags", `<i>see <a href="https://go.dev">Go</a></i>`, "*see [Go](https://go.dev)*"},
+		{"entities", "Tom &amp; Jerry &#x27;quoted&#x27; &lt;tag&gt; &#x2F;path", "Tom & Jerry 'quoted' <tag> /path"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hnHTMLToMarkdown(tt.in); got != tt.want {
+				t.Errorf("hnHTMLToMarkdown(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
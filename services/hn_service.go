@@ -1,10 +1,10 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"sort"
 	"strings"
@@ -14,43 +14,80 @@ import (
 	"github.com/hacker-news-ai/models"
 )
 
+// hnListEndpoints HN 文章列表及其接口名称
+var hnListEndpoints = map[string]string{
+	"top":  "topstories",
+	"best": "beststories",
+	"new":  "newstories",
+	"ask":  "askstories",
+	"show": "showstories",
+	"job":  "jobstories",
+}
+
+// hnItem HN 条目 JSON 结构，type 为 story、job、poll、pollopt 或 comment
+type hnItem struct {
+	ID          int    `json:"id"`
+	Type        string `json:"type"`
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	Text        string `json:"text"`
+	Score       int    `json:"score"`
+	Time        int64  `json:"time"`
+	By          string `json:"by"`
+	Descendants int    `json:"descendants"`
+	Kids        []int  `json:"kids"`
+	// 投票帖的选项ID
+	Parts []int `json:"parts"`
+}
+
 type HNService struct {
-	config *config.Config
-	client *http.Client
+	config    *config.Config
+	client    *http.Client
+	extractor *ContentExtractor
+	// 来源标识，hn 或 hn_<列表>
+	name string
+	// 读取的文章列表，多个列表合并去重
+	lists []string
 }
 
+// NewHNService 创建 hn 来源，读取 hn_lists 配置的列表
 func NewHNService(cfg *config.Config) *HNService {
+	return newHNService(cfg, "hn", cfg.HNLists)
+}
+
+// NewHNListService 创建只读取单个列表的来源，单独生成一期精选（如 hn_show）
+func NewHNListService(cfg *config.Config, list string) *HNService {
+	return newHNService(cfg, "hn_"+list, []string{list})
+}
+
+func newHNService(cfg *config.Config, name string, lists []string) *HNService {
 	return &HNService{
-		config: cfg,
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		config:    cfg,
+		client:    newHTTPClient(10 * time.Second),
+		extractor: NewContentExtractor(),
+		name:      name,
+		lists:     lists,
 	}
 }
 
+func (s *HNService) Name() string {
+	return s.name
+}
+
 // FetchTopStories 获取热门文章列表
-func (s *HNService) FetchTopStories() ([]models.Story, error) {
-	// 获取热门文章ID列表
-	resp, err := s.client.Get(fmt.Sprintf("%s/topstories.json", s.config.HNAPIBaseURL))
+func (s *HNService) FetchTopStories(ctx context.Context) ([]models.Story, error) {
+	storyIDs, err := s.storyIDs(ctx, s.config.Source(s.name).Candidates)
 	if err != nil {
-		return nil, fmt.Errorf("获取热门文章列表失败: %v", err)
-	}
-	defer resp.Body.Close()
-
-	var storyIDs []int
-	if err := json.NewDecoder(resp.Body).Decode(&storyIDs); err != nil {
-		return nil, fmt.Errorf("解析热门文章列表失败: %v", err)
-	}
-
-	// 限制获取的文章数量
-	if len(storyIDs) > s.config.TopStoriesLimit {
-		storyIDs = storyIDs[:s.config.TopStoriesLimit]
+		return nil, err
 	}
 
 	// 获取每个文章的详细信息
 	var stories []models.Story
 	for _, id := range storyIDs {
-		story, err := s.FetchStory(id)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		story, err := s.FetchStory(ctx, id)
 		if err != nil {
 			continue
 		}
@@ -60,131 +97,213 @@ func (s *HNService) FetchTopStories() ([]models.Story, error) {
 	return stories, nil
 }
 
-// FetchStory 获取单个文章的详细信息
-func (s *HNService) FetchStory(id int) (models.Story, error) {
-	var story models.Story
+// FetchCandidates 获取候选文章的元数据，正文在排序选定后再获取
+func (s *HNService) FetchCandidates(ctx context.Context, limit int) ([]models.Story, error) {
+	storyIDs, err := s.storyIDs(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var stories []models.Story
+	for _, id := range storyIDs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var item hnItem
+		if err := s.getItem(ctx, id, &item); err != nil {
+			slog.WarnContext(ctx, "获取文章详情失败", LogStoryID, id, "error", err)
+			continue
+		}
+		stories = append(stories, newHNStory(item))
+	}
+	return stories, nil
+}
 
-	resp, err := s.client.Get(fmt.Sprintf("%s/item/%d.json", s.config.HNAPIBaseURL, id))
+// FetchContent 获取选中文章的正文和评论
+func (s *HNService) FetchContent(ctx context.Context, story *models.Story) error {
+	var item hnItem
+	if err := s.getItem(ctx, story.ID, &item); err != nil {
+		return fmt.Errorf("获取文章详情失败: %v", err)
+	}
+	content, err := s.fetchContent(ctx, item)
 	if err != nil {
-		return story, fmt.Errorf("获取文章详情失败: %v", err)
+		return fmt.Errorf("获取文章内容失败: %v", err)
+	}
+	story.Content = content
+	return nil
+}
+
+// storyIDs 获取配置列表中的文章ID
+func (s *HNService) storyIDs(ctx context.Context, limit int) ([]int, error) {
+	// 获取各列表的文章ID
+	var lists [][]int
+	for _, list := range s.lists {
+		ids, err := s.fetchList(ctx, list)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, ids)
+	}
+
+	// 多个列表轮流取文章并去重，避免排在前面的列表占满数量
+	var storyIDs []int
+	seen := make(map[int]bool)
+	for i := 0; ; i++ {
+		added := false
+		for _, ids := range lists {
+			if i >= len(ids) {
+				continue
+			}
+			added = true
+			if !seen[ids[i]] {
+				seen[ids[i]] = true
+				storyIDs = append(storyIDs, ids[i])
+			}
+		}
+		if !added {
+			break
+		}
+	}
+
+	// 限制获取的文章数量
+	if len(storyIDs) > limit {
+		storyIDs = storyIDs[:limit]
+	}
+	return storyIDs, nil
+}
+
+// fetchList 获取单个列表的文章ID
+func (s *HNService) fetchList(ctx context.Context, list string) ([]int, error) {
+	endpoint, ok := hnListEndpoints[list]
+	if !ok {
+		return nil, fmt.Errorf("未知的HN文章列表: %s", list)
+	}
+	resp, err := httpGet(ctx, s.client, fmt.Sprintf("%s/%s.json", s.config.HNAPIBaseURL, endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("获取%s文章列表失败: %v", list, err)
 	}
 	defer resp.Body.Close()
 
-	// 解析API响应
-	var rawStory struct {
-		ID          int    `json:"id"`
-		Title       string `json:"title"`
-		URL         string `json:"url"`
-		Score       int    `json:"score"`
-		Time        int64  `json:"time"`
-		By          string `json:"by"`
-		Descendants int    `json:"descendants"`
+	var ids []int
+	if err := json.NewDecoder(resp.Body).Decode(&ids); err != nil {
+		return nil, fmt.Errorf("解析%s文章列表失败: %v", list, err)
 	}
+	return ids, nil
+}
 
-	if err := json.NewDecoder(resp.Body).Decode(&rawStory); err != nil {
-		return story, fmt.Errorf("解析文章详情失败: %v", err)
+// FetchStory 获取单个文章的详细信息
+func (s *HNService) FetchStory(ctx context.Context, id int) (models.Story, error) {
+	var story models.Story
+
+	var item hnItem
+	if err := s.getItem(ctx, id, &item); err != nil {
+		return story, fmt.Errorf("获取文章详情失败: %v", err)
 	}
 
 	// 获取文章的原始内容和评论
-	content, err := s.fetchContent(rawStory.URL, rawStory.ID)
+	content, err := s.fetchContent(ctx, item)
 	if err != nil {
 		return story, fmt.Errorf("获取文章内容失败: %v", err)
 	}
-	// 转换为Story模型
-	story = models.Story{
-		ID:          rawStory.ID,
-		Title:       rawStory.Title,
-		URL:         getStoryURL(rawStory.URL, rawStory.ID),
-		Score:       rawStory.Score,
-		Time:        time.Unix(rawStory.Time, 0),
-		By:          rawStory.By,
-		Descendants: rawStory.Descendants,
-		Content:     content,
-	}
+	story = newHNStory(item)
+	story.Content = content
 
 	return story, nil
 }
 
-// getStoryURL 获取文章URL，如果原始URL为空则使用HN默认链接
-func getStoryURL(originalURL string, storyID int) string {
-	if originalURL != "" {
-		return originalURL
+// newHNStory 将 HN 条目转换为Story模型，不包含正文
+func newHNStory(item hnItem) models.Story {
+	story := models.Story{
+		ID:            item.ID,
+		Title:         item.Title,
+		URL:           getStoryURL(item.URL, item.ID),
+		DiscussionURL: fmt.Sprintf("https://news.ycombinator.com/item?id=%d", item.ID),
+		Score:         item.Score,
+		Time:          time.Unix(item.Time, 0),
+		By:            item.By,
+		Descendants:   item.Descendants,
 	}
-	return fmt.Sprintf("https://news.ycombinator.com/item?id=%d", storyID)
-}
-
-// fetchContent 获取文章的原始内容和评论
-func (s *HNService) fetchContent(url string, storyID int) (string, error) {
-	// 设置请求头
-	headers := make(http.Header)
-	headers.Set("X-Retain-Images", "none")
-
-	// 获取文章内容
-	req, err := http.NewRequest("GET", "https://r.jina.ai/"+url, nil)
-	if err != nil {
-		return "", fmt.Errorf("创建文章请求失败: %v", err)
+	if item.Type == "job" {
+		story.Kind = models.KindJob
 	}
-	req.Header = headers
+	return story
+}
 
-	resp, err := s.client.Do(req)
+// getItem 获取 HN 条目
+func (s *HNService) getItem(ctx context.Context, id int, v interface{}) error {
+	resp, err := httpGet(ctx, s.client, fmt.Sprintf("%s/item/%d.json", s.config.HNAPIBaseURL, id))
 	if err != nil {
-		return "", fmt.Errorf("获取文章内容失败: %v", err)
+		return err
 	}
 	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(v)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("获取文章失败: %s %s", resp.Status, url)
+// getStoryURL 获取文章URL，如果原始URL为空则使用HN默认链接
+func getStoryURL(originalURL string, storyID int) string {
+	if originalURL != "" {
+		return originalURL
 	}
+	return fmt.Sprintf("https://news.ycombinator.com/item?id=%d", storyID)
+}
 
-	articleBody, err := io.ReadAll(resp.Body)
+// fetchContent 按条目类型获取文章内容和评论
+func (s *HNService) fetchContent(ctx context.Context, item hnItem) (string, error) {
+	articleBody, err := s.fetchArticle(ctx, item)
 	if err != nil {
-		return "", fmt.Errorf("读取文章内容失败: %v", err)
+		return "", err
 	}
 
-	// 获取评论内容
-	commentsBody, err := s.fetchComments(storyID)
+	// 获取评论内容，招聘帖没有评论
+	if len(item.Kids) == 0 {
+		return buildContent(articleBody, ""), nil
+	}
+	commentsCtx, span := StartSpan(ctx, "comments")
+	commentsBody, err := s.fetchComments(commentsCtx, item.Kids)
+	EndSpan(span, err)
 	if err != nil {
-		log.Printf("获取评论内容失败: %v", err)
+		slog.WarnContext(ctx, "获取评论内容失败", LogStoryID, item.ID, "error", err)
 		// 评论获取失败不影响返回文章内容
-		return fmt.Sprintf("\n<article>\n%s\n</article>\n", string(articleBody)), nil
+		commentsBody = ""
 	}
 
-	// 构建返回内容
-	var parts []string
-
-	// 添加文章内容
-	parts = append(parts, fmt.Sprintf("\n<article>\n%s\n</article>\n", string(articleBody)))
-
-	// 添加评论内容
-	parts = append(parts, fmt.Sprintf("\n<comments>\n%s\n</comments>\n", string(commentsBody)))
-
-	// 合并所有内容
-	content := strings.Join(parts, "\n---\n")
+	return buildContent(articleBody, commentsBody), nil
+}
 
-	// 限制内容长度，避免token过多
-	if len(content) > 8000 {
-		content = content[:8000]
+// fetchArticle 获取正文：投票帖附带选项和票数，文本帖（如 Ask HN）使用帖子正文，链接帖提取原文
+func (s *HNService) fetchArticle(ctx context.Context, item hnItem) (string, error) {
+	text := hnHTMLToMarkdown(item.Text)
+
+	if item.Type == "poll" {
+		var options []string
+		for _, id := range item.Parts {
+			var option hnItem
+			if err := s.getItem(ctx, id, &option); err != nil {
+				slog.WarnContext(ctx, "获取投票选项失败", LogStoryID, item.ID, "option_id", id, "error", err)
+				continue
+			}
+			options = append(options, fmt.Sprintf("- %s（%d 票）", hnHTMLToMarkdown(option.Text), option.Score))
+		}
+		return fmt.Sprintf("%s\n\n投票选项：\n%s", text, strings.Join(options, "\n")), nil
 	}
 
-	return content, nil
-}
-
-// fetchComments 获取文章的评论内容
-func (s *HNService) fetchComments(storyID int) (string, error) {
-	// 获取评论ID列表
-	resp, err := s.client.Get(fmt.Sprintf("%s/item/%d.json", s.config.HNAPIBaseURL, storyID))
-	if err != nil {
-		return "", fmt.Errorf("获取评论列表失败: %v", err)
+	if item.URL == "" {
+		return text, nil
 	}
-	defer resp.Body.Close()
 
-	var item struct {
-		Kids []int `json:"kids"`
+	articleBody, err := s.extractor.Extract(ctx, item.URL)
+	if err != nil {
+		return "", err
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&item); err != nil {
-		return "", fmt.Errorf("解析评论列表失败: %v", err)
+	// 带链接的 Show HN 等帖子可能附有作者说明
+	if text != "" {
+		articleBody = fmt.Sprintf("提交者说明：%s\n\n%s", text, articleBody)
 	}
+	return articleBody, nil
+}
 
+// fetchComments 获取文章的评论内容
+func (s *HNService) fetchComments(ctx context.Context, kids []int) (string, error) {
 	// 获取评论内容和得分
 	type commentInfo struct {
 		text  string
@@ -194,25 +313,18 @@ func (s *HNService) fetchComments(storyID int) (string, error) {
 	var commentsWithScore []commentInfo
 
 	// 获取所有评论的内容和得分
-	for _, commentID := range item.Kids {
-		commentResp, err := s.client.Get(fmt.Sprintf("%s/item/%d.json", s.config.HNAPIBaseURL, commentID))
-		if err != nil {
+	for _, commentID := range kids {
+		var comment hnItem
+		if err := s.getItem(ctx, commentID, &comment); err != nil {
 			continue
 		}
-
-		var comment struct {
-			Text  string `json:"text"`
-			By    string `json:"by"`
-			Score int    `json:"score"`
-		}
-		if err := json.NewDecoder(commentResp.Body).Decode(&comment); err != nil {
-			commentResp.Body.Close()
+		// 跳过已删除的评论
+		if comment.Text == "" {
 			continue
 		}
-		commentResp.Body.Close()
 
 		commentsWithScore = append(commentsWithScore, commentInfo{
-			text:  comment.Text,
+			text:  hnHTMLToMarkdown(comment.Text),
 			by:    comment.By,
 			score: comment.Score,
 		})
@@ -235,5 +347,5 @@ func (s *HNService) fetchComments(storyID int) (string, error) {
 		comments = append(comments, fmt.Sprintf("@%s (得分:%d): %s", comment.by, comment.score, comment.text))
 	}
 
-	return strings.Join(comments, "\n"), nil
+	return strings.Join(comments, "\n\n"), nil
 }
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hacker-news-ai/config"
+	"github.com/hacker-news-ai/models"
+)
+
+// newFakeHNAPI 按路径返回 HN 条目 JSON，未配置的条目返回 404
+func newFakeHNAPI(t *testing.T, items map[string]string) *config.Config {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := items[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return &config.Config{HNAPIBaseURL: server.URL}
+}
+
+func TestHNFetchStoryFormatsPollAndJob(t *testing.T) {
+	cfg := newFakeHNAPI(t, map[string]string{
+		"/item/1.json":  `{"id":1,"type":"poll","title":"Poll: Favourite editor?","text":"Pick <i>one</i>.","score":50,"time":1741996800,"by":"pg","parts":[2,3,4]}`,
+		"/item/2.json":  `{"id":2,"type":"pollopt","text":"Vim","score":120}`,
+		"/item/3.json":  `{"id":3,"type":"pollopt","text":"Emacs &amp; Evil","score":80}`,
+		"/item/10.json": `{"id":10,"type":"job","title":"Acme (YC S21) is hiring","text":"We build <a href=\"https://acme.example/jobs\">tools</a>.<p>Remote OK.","time":1741996800,"by":"acme"}`,
+	})
+	service := NewHNService(cfg)
+
+	tests := []struct {
+		name    string
+		id      int
+		kind    string
+		url     string
+		article string
+	}{
+		// 获取失败的选项（4）被跳过
+		{"poll", 1, "", "https://news.ycombinator.com/item?id=1",
+			"Pick *one*.\n\n投票选项：\n- Vim（120 票）\n- Emacs & Evil（80 票）"},
+		// 招聘帖没有链接和评论，正文为帖子内容
+		{"job", 10, models.KindJob, "https://news.ycombinator.com/item?id=10",
+			"We build [tools](https://acme.example/jobs).\n\nRemote OK."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			story, err := service.FetchStory(context.Background(), tt.id)
+			if err != nil {
+				t.Fatalf("获取文章失败: %v", err)
+			}
+			if story.Kind != tt.kind || story.URL != tt.url {
+				t.Errorf("kind = %q, url = %q, want %q, %q", story.Kind, story.URL, tt.kind, tt.url)
+			}
+			if want := "<article>\n" + tt.article + "\n</article>"; !strings.Contains(story.Content, want) {
+				t.Errorf("正文 = %q, want %q", story.Content, want)
+			}
+			if strings.Contains(story.Content, "<comments>") {
+				t.Errorf("没有评论时不应包含评论部分: %q", story.Content)
+			}
+		})
+	}
+}
+
+// fakeReaderTransport 代替 r.jina.ai 返回固定正文，记录请求的地址
+type fakeReaderTransport struct {
+	requests []string
+}
+
+func (t *fakeReaderTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests = append(t.requests, req.URL.String())
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader("原文正文")),
+		Request:    req,
+	}, nil
+}
+
+func TestHNFetchStoryTextPostSkipsReader(t *testing.T) {
+	cfg := newFakeHNAPI(t, map[string]string{
+		"/item/1.json":   `{"id":1,"type":"story","title":"Ask HN: How do you back up?","text":"I use rsync &amp; cron.<p>Any tips?","time":1741996800,"by":"pg","kids":[100]}`,
+		"/item/2.json":   `{"id":2,"type":"story","title":"Show HN: Backup tool","url":"https://backup.example","text":"Made this.","time":1741996800,"by":"dang"}`,
+		"/item/100.json": `{"id":100,"type":"comment","text":"restic","by":"x","score":3}`,
+	})
+	tests := []struct {
+		name     string
+		id       int
+		url      string
+		prompt   string
+		requests []string
+	}{
+		// 没有链接的文本帖使用帖子正文生成提示词，不请求 r.jina.ai
+		{"ask hn", 1, "https://news.ycombinator.com/item?id=1", "I use rsync & cron.\n\nAny tips?", nil},
+		{"link post", 2, "https://backup.example", "提交者说明：Made this.\n\n原文正文", []string{"https://r.jina.ai/https://backup.example"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewHNService(cfg)
+			reader := &fakeReaderTransport{}
+			service.extractor.client = &http.Client{Transport: reader}
+
+			story, err := service.FetchStory(context.Background(), tt.id)
+			if err != nil {
+				t.Fatalf("获取文章失败: %v", err)
+			}
+			if story.URL != tt.url {
+				t.Errorf("url = %q, want %q", story.URL, tt.url)
+			}
+			if !reflect.DeepEqual(reader.requests, tt.requests) {
+				t.Errorf("正文请求 = %v, want %v", reader.requests, tt.requests)
+			}
+			if prompt := buildPrompt("", story); !strings.Contains(prompt, "<article>\n"+tt.prompt+"\n</article>") || !strings.Contains(prompt, story.Title) {
+				t.Errorf("提示词 = %q, want 包含 %q", prompt, tt.prompt)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hacker-news-ai/config"
+	"github.com/hacker-news-ai/models"
+)
+
+// lobstersUser 用户字段旧版 API 为对象，新版 API 为用户名字符串
+type lobstersUser string
+
+func (u *lobstersUser) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*u = lobstersUser(name)
+		return nil
+	}
+	var user struct {
+		Username string `json:"username"`
+	}
+	if err := json.Unmarshal(data, &user); err != nil {
+		return err
+	}
+	*u = lobstersUser(user.Username)
+	return nil
+}
+
+// lobstersStory Lobsters 文章 JSON 结构
+type lobstersStory struct {
+	ShortID          string       `json:"short_id"`
+	ShortIDURL       string       `json:"short_id_url"`
+	CreatedAt        time.Time    `json:"created_at"`
+	Title            string       `json:"title"`
+	URL              string       `json:"url"`
+	Score            int          `json:"score"`
+	CommentCount     int          `json:"comment_count"`
+	DescriptionPlain string       `json:"description_plain"`
+	CommentsURL      string       `json:"comments_url"`
+	SubmitterUser    lobstersUser `json:"submitter_user"`
+	Tags             []string     `json:"tags"`
+	Comments         []struct {
+		CommentPlain   string       `json:"comment_plain"`
+		Score          int          `json:"score"`
+		Depth          int          `json:"depth"`
+		IsDeleted      bool         `json:"is_deleted"`
+		CommentingUser lobstersUser `json:"commenting_user"`
+	} `json:"comments"`
+}
+
+type LobstersService struct {
+	config    *config.Config
+	client    *http.Client
+	extractor *ContentExtractor
+}
+
+func NewLobstersService(cfg *config.Config) *LobstersService {
+	return &LobstersService{
+		config:    cfg,
+		client:    newHTTPClient(10 * time.Second),
+		extractor: NewContentExtractor(),
+	}
+}
+
+func (s *LobstersService) Name() string {
+	return "lobsters"
+}
+
+// FetchTopStories 获取Lobsters热门文章列表
+func (s *LobstersService) FetchTopStories(ctx context.Context) ([]models.Story, error) {
+	// 获取热门或最新文章列表
+	resp, err := httpGet(ctx, s.client, fmt.Sprintf("%s/%s.json", s.config.LobstersAPIBaseURL, s.config.LobstersList))
+	if err != nil {
+		return nil, fmt.Errorf("获取Lobsters文章列表失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var list []lobstersStory
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("解析Lobsters文章列表失败: %v", err)
+	}
+
+	// 限制获取的文章数量
+	if len(list) > s.config.Source(s.Name()).Candidates {
+		list = list[:s.config.Source(s.Name()).Candidates]
+	}
+
+	// 获取每个文章的详细信息
+	var stories []models.Story
+	for _, item := range list {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		story, err := s.FetchStory(ctx, item.ShortID)
+		if err != nil {
+			slog.WarnContext(ctx, "获取Lobsters文章失败", "short_id", item.ShortID, "error", err)
+			continue
+		}
+		stories = append(stories, story)
+	}
+
+	return stories, nil
+}
+
+// FetchStory 获取单个文章的详细信息和评论
+func (s *LobstersService) FetchStory(ctx context.Context, shortID string) (models.Story, error) {
+	var story models.Story
+
+	resp, err := httpGet(ctx, s.client, fmt.Sprintf("%s/s/%s.json", s.config.LobstersAPIBaseURL, shortID))
+	if err != nil {
+		return story, fmt.Errorf("获取Lobsters文章详情失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var item lobstersStory
+	if err := json.NewDecoder(resp.Body).Decode(&item); err != nil {
+		return story, fmt.Errorf("解析Lobsters文章详情失败: %v", err)
+	}
+
+	// 文本帖子直接使用帖子内容，链接帖子提取原文
+	article := item.DescriptionPlain
+	url := item.URL
+	if url == "" {
+		url = item.ShortIDURL
+	} else {
+		extracted, err := s.extractor.Extract(ctx, url)
+		if err != nil {
+			return story, fmt.Errorf("获取文章内容失败: %v", err)
+		}
+		if article != "" {
+			article = fmt.Sprintf("提交者说明：%s\n\n%s", article, extracted)
+		} else {
+			article = extracted
+		}
+	}
+
+	// short_id 为 36 进制字符串，转换为整数作为稳定的文章 ID
+	id, err := strconv.ParseInt(item.ShortID, 36, 64)
+	if err != nil {
+		return story, fmt.Errorf("解析Lobsters文章ID失败: %v", err)
+	}
+
+	story = models.Story{
+		ID:            int(id),
+		Title:         item.Title,
+		URL:           url,
+		DiscussionURL: item.ShortIDURL,
+		Score:         item.Score,
+		Time:          item.CreatedAt,
+		By:            string(item.SubmitterUser),
+		Descendants:   item.CommentCount,
+		Tags:          item.Tags,
+		Content:       buildContent(article, s.formatComments(item)),
+	}
+
+	return story, nil
+}
+
+// formatComments 按讨论串格式化评论，保留前 lobsters_comment_threads 个讨论串及其两层回复
+func (s *LobstersService) formatComments(item lobstersStory) string {
+	var comments []string
+	threads := 0
+	for _, comment := range item.Comments {
+		if comment.Depth == 0 {
+			threads++
+		}
+		if threads > s.config.LobstersCommentThreads {
+			break
+		}
+		if comment.IsDeleted || comment.CommentPlain == "" || comment.Depth > 2 {
+			continue
+		}
+		indent := strings.Repeat("  ", comment.Depth)
+		text := strings.ReplaceAll(comment.CommentPlain, "\n", "\n"+indent)
+		comments = append(comments, fmt.Sprintf("%s@%s (得分:%d): %s", indent, comment.CommentingUser, comment.Score, text))
+	}
+	return strings.Join(comments, "\n")
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hacker-news-ai/config"
+	"github.com/hacker-news-ai/models"
+)
+
+// newFixtureServer 按路径返回 testdata 中的接口数据，未配置的路径返回 404
+func newFixtureServer(t *testing.T, files map[string]string) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Errorf("读取测试数据失败: %v", err)
+		}
+		w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestLobstersFetchStory(t *testing.T) {
+	baseURL := newFixtureServer(t, map[string]string{"/s/abc12.json": "testdata/lobsters_story.json"})
+	tests := []struct {
+		name     string
+		threads  int
+		comments string
+	}{
+		// 超过两层的回复和已删除的评论跳过，旧版 API 的用户对象同样可以解析
+		{"two threads", 2, "\n---\n\n<comments>\n" +
+			"@bob (得分:10): Make, still.\n" +
+			"  @carol (得分:5): Why not Bazel?\n  It scales.\n" +
+			"    @bob (得分:3): Too heavy.\n" +
+			"@frank (得分:8): Nix flakes.\n" +
+			"</comments>\n"},
+		{"no comments", 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{LobstersAPIBaseURL: baseURL, LobstersCommentThreads: tt.threads}
+			story, err := NewLobstersService(cfg).FetchStory(context.Background(), "abc12")
+			if err != nil {
+				t.Fatalf("获取文章失败: %v", err)
+			}
+
+			// 文本帖链接到讨论页，short_id 按 36 进制转换为文章 ID
+			want := models.Story{
+				ID:            17324966,
+				Title:         "Ask: What is your favourite build system?",
+				URL:           "https://lobste.rs/s/abc12",
+				DiscussionURL: "https://lobste.rs/s/abc12",
+				Score:         42,
+				Time:          time.Date(2025, 3, 15, 13, 0, 0, 0, time.UTC),
+				By:            "alice",
+				Descendants:   7,
+				Tags:          []string{"ask", "devops"},
+				Content:       "\n<article>\nCurious what people use.\n</article>\n" + tt.comments,
+			}
+			story.Time = story.Time.UTC()
+			if !reflect.DeepEqual(story, want) {
+				t.Errorf("文章 =\n%+v\nwant\n%+v", story, want)
+			}
+		})
+	}
+}
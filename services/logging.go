@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/hacker-news-ai/config"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// 日志属性名，便于在日志系统中按运行、来源、文章和阶段检索
+const (
+	LogRunID    = "run_id"
+	LogSource   = "source"
+	LogStoryID  = "story_id"
+	LogStage    = "stage"
+	LogDuration = "duration_ms"
+	LogTraceID  = "trace_id"
+)
+
+// logAttrsKey ctx 中日志属性的键
+type logAttrsKey struct{}
+
+// WithLogAttrs 返回附加了日志属性的 ctx，使用该 ctx 记录的日志都会带上这些属性
+func WithLogAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	existing, _ := ctx.Value(logAttrsKey{}).([]slog.Attr)
+	merged := make([]slog.Attr, 0, len(existing)+len(attrs))
+	merged = append(merged, existing...)
+	merged = append(merged, attrs...)
+	return context.WithValue(ctx, logAttrsKey{}, merged)
+}
+
+// NewLogger 按配置创建日志，format 为 text 或 json
+func NewLogger(cfg config.LogConfig, w io.Writer) *slog.Logger {
+	options := &slog.HandlerOptions{Level: parseLogLevel(cfg.Level)}
+	var handler slog.Handler
+	if cfg.Format == "json" {
+		handler = slog.NewJSONHandler(w, options)
+	} else {
+		handler = slog.NewTextHandler(w, options)
+	}
+	return slog.New(contextHandler{handler})
+}
+
+// parseLogLevel 解析日志级别，无法识别时使用 info
+func parseLogLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// contextHandler 将 ctx 中的日志属性和链路ID添加到每条日志
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs, ok := ctx.Value(logAttrsKey{}).([]slog.Attr); ok {
+		r.AddAttrs(attrs...)
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		r.AddAttrs(slog.String(LogTraceID, spanContext.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/hacker-news-ai/config"
+	"github.com/hacker-news-ai/models"
+	"gopkg.in/yaml.v3"
+)
+
+// MarkdownPublisher 将精选内容写入静态博客（Hugo/Jekyll/Hexo）的 Markdown 文件
+type MarkdownPublisher struct {
+	config config.MarkdownConfig
+}
+
+// markdownPost 索引页中的一篇文章
+type markdownPost struct {
+	title     string
+	date      time.Time
+	permalink string
+	path      string
+}
+
+// frontMatterField 头信息中的一个字段，按添加顺序输出
+type frontMatterField struct {
+	key   string
+	value interface{}
+}
+
+func NewMarkdownPublisher(cfg config.MarkdownConfig) *MarkdownPublisher {
+	return &MarkdownPublisher{
+		config: cfg,
+	}
+}
+
+func (p *MarkdownPublisher) Name() string {
+	return "markdown"
+}
+
+// Publish 按 年/月 目录写入 Markdown 文件并重新生成索引页
+func (p *MarkdownPublisher) Publish(ctx context.Context, digest *models.Digest) error {
+	slug := strings.ToLower(digest.Pid)
+	dir := filepath.Join(p.config.Dir, digest.CreatedAt.Format("2006"), digest.CreatedAt.Format("01"))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %v", err)
+	}
+
+	frontMatter, err := p.frontMatter(digest.Title, digest.CreatedAt, slug, p.permalink(slug, digest.CreatedAt), digest.Cover)
+	if err != nil {
+		return err
+	}
+	content := frontMatter + "\n" + digest.Content + "\n"
+	if err := os.WriteFile(filepath.Join(dir, slug+".md"), []byte(content), 0644); err != nil {
+		return fmt.Errorf("写入Markdown文件失败: %v", err)
+	}
+
+	if err := p.writeIndex(); err != nil {
+		return fmt.Errorf("生成索引页失败: %v", err)
+	}
+
+	return nil
+}
+
+// permalink 按 markdown.permalink 生成文章地址
+func (p *MarkdownPublisher) permalink(slug string, date time.Time) string {
+	return strings.NewReplacer(
+		"{year}", date.Format("2006"),
+		"{month}", date.Format("01"),
+		"{day}", date.Format("02"),
+		"{slug}", slug,
+	).Replace(p.config.Permalink)
+}
+
+// frontMatter 根据博客类型生成文章头信息，slug 和 permalink 为空时不输出
+// 文章地址写入头信息（Hugo 为 url，Jekyll 和 Hexo 为 permalink），索引页的链接与博客生成的地址一致
+func (p *MarkdownPublisher) frontMatter(title string, date time.Time, slug, permalink, cover string) (string, error) {
+	tags := p.config.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	var fields []frontMatterField
+	if p.config.Style == "jekyll" {
+		fields = append(fields, frontMatterField{"layout", "post"})
+	}
+	fields = append(fields, frontMatterField{"title", title}, frontMatterField{"date", date})
+	if slug != "" {
+		fields = append(fields, frontMatterField{"slug", slug})
+	}
+	if permalink != "" {
+		if p.config.Style == "hugo" {
+			fields = append(fields, frontMatterField{"url", permalink})
+		} else {
+			fields = append(fields, frontMatterField{"permalink", permalink})
+		}
+	}
+	fields = append(fields, frontMatterField{"tags", tags})
+	for _, key := range sortedKeys(p.config.Extra) {
+		// 与固定字段重名的额外字段会产生重复的键，跳过
+		if !slices.ContainsFunc(fields, func(field frontMatterField) bool { return field.key == key }) {
+			fields = append(fields, frontMatterField{key, p.config.Extra[key]})
+		}
+	}
+	if cover != "" {
+		switch p.config.Style {
+		case "hugo":
+			// TOML 的表需要放在最后
+			fields = append(fields, frontMatterField{"cover", map[string]string{"image": cover}})
+		case "jekyll":
+			fields = append(fields, frontMatterField{"image", cover})
+		default:
+			fields = append(fields, frontMatterField{"cover", cover})
+		}
+	}
+
+	var b bytes.Buffer
+	for _, field := range fields {
+		if p.config.Style == "hugo" {
+			// Hugo 使用 TOML 格式
+			if err := toml.NewEncoder(&b).Encode(map[string]interface{}{field.key: field.value}); err != nil {
+				return "", fmt.Errorf("生成头信息失败: %v", err)
+			}
+			continue
+		}
+		// Jekyll 和 Hexo 使用 YAML 格式
+		encoder := yaml.NewEncoder(&b)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(map[string]interface{}{field.key: field.value}); err != nil {
+			return "", fmt.Errorf("生成头信息失败: %v", err)
+		}
+		encoder.Close()
+	}
+	delimiter := "---\n"
+	if p.config.Style == "hugo" {
+		delimiter = "+++\n"
+	}
+	return delimiter + b.String() + delimiter, nil
+}
+
+// writeIndex 扫描输出目录，重新生成按时间倒序排列的索引页
+func (p *MarkdownPublisher) writeIndex() error {
+	indexName := "index.md"
+	if p.config.Style == "hugo" {
+		indexName = "_index.md"
+	}
+
+	var posts []markdownPost
+	err := filepath.Walk(p.config.Dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != ".md" || filepath.Base(path) == indexName {
+			return nil
+		}
+		post, err := readFrontMatter(path)
+		if err != nil {
+			return fmt.Errorf("读取 %s 失败: %v", path, err)
+		}
+		rel, err := filepath.Rel(p.config.Dir, path)
+		if err != nil {
+			return err
+		}
+		post.path = filepath.ToSlash(rel)
+		if post.permalink == "" {
+			// 头信息中没有地址的旧文章按当前规则生成
+			post.permalink = p.permalink(strings.TrimSuffix(filepath.Base(path), ".md"), post.date)
+		}
+		posts = append(posts, post)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// 按发布日期倒序
+	sort.Slice(posts, func(i, j int) bool {
+		if !posts[i].date.Equal(posts[j].date) {
+			return posts[i].date.After(posts[j].date)
+		}
+		return posts[i].path > posts[j].path
+	})
+
+	frontMatter, err := p.frontMatter(p.config.IndexTitle, time.Now(), "", "", "")
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	b.WriteString(frontMatter)
+	month := ""
+	for _, post := range posts {
+		if m := filepath.Dir(post.path); m != month {
+			month = m
+			fmt.Fprintf(&b, "\n## %s\n\n", strings.ReplaceAll(month, "/", "-"))
+		}
+		fmt.Fprintf(&b, "- [%s](%s)\n", markdownEscaper.Replace(post.title), markdownURLEscaper.Replace(post.permalink))
+	}
+
+	return os.WriteFile(filepath.Join(p.config.Dir, indexName), []byte(b.String()), 0644)
+}
+
+// readFrontMatter 读取文章头信息中的标题、日期和地址，+++ 包围的为 TOML，--- 包围的为 YAML
+func readFrontMatter(path string) (markdownPost, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return markdownPost{}, err
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	if len(lines) == 0 {
+		return markdownPost{}, nil
+	}
+	delimiter := strings.TrimSpace(lines[0])
+	if delimiter != "---" && delimiter != "+++" {
+		return markdownPost{}, nil
+	}
+	end := slices.IndexFunc(lines[1:], func(line string) bool { return strings.TrimSpace(line) == delimiter })
+	if end < 0 {
+		return markdownPost{}, errors.New("头信息没有结束标记")
+	}
+	body := strings.Join(lines[1:end+1], "")
+
+	fields := map[string]interface{}{}
+	if delimiter == "+++" {
+		_, err = toml.Decode(body, &fields)
+	} else {
+		err = yaml.Unmarshal([]byte(body), &fields)
+	}
+	if err != nil {
+		return markdownPost{}, fmt.Errorf("解析头信息失败: %v", err)
+	}
+
+	var post markdownPost
+	post.title, _ = fields["title"].(string)
+	for _, key := range []string{"url", "permalink"} {
+		if permalink, ok := fields[key].(string); ok {
+			post.permalink = permalink
+		}
+	}
+	switch date := fields["date"].(type) {
+	case time.Time:
+		post.date = date
+	case string:
+		// 兼容未使用时间类型写入的日期
+		for _, layout := range []string{"2006-01-02 15:04:05 -0700", "2006-01-02 15:04:05", time.RFC3339, time.DateOnly} {
+			if t, err := time.Parse(layout, date); err == nil {
+				post.date = t
+				break
+			}
+		}
+	}
+	return post, nil
+}
+
+// sortedKeys 返回排序后的键，保证输出稳定
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/hacker-news-ai/config"
+	"github.com/hacker-news-ai/models"
+	"gopkg.in/yaml.v3"
+)
+
+// newTestMarkdownPublisher 创建输出到临时目录的发布目标
+func newTestMarkdownPublisher(t *testing.T, style string) *MarkdownPublisher {
+	t.Helper()
+	return NewMarkdownPublisher(config.MarkdownConfig{
+		Dir:        t.TempDir(),
+		Style:      style,
+		Tags:       []string{"Hacker News"},
+		Extra:      map[string]string{"categories": `日报 "AI"`, "title": "重名字段"},
+		IndexTitle: "归档",
+		Permalink:  "/{year}/{month}/{slug}/",
+	})
+}
+
+func TestMarkdownFrontMatter(t *testing.T) {
+	// 标题中的引号、反斜杠、控制字符和 emoji 需要按 YAML/TOML 规则转义
+	title := "Go 1.24: \"泛型\" C:\\dir\x01 🚀"
+	date := time.Date(2025, 3, 15, 12, 0, 0, 0, time.FixedZone("CST", 8*3600))
+	tests := []struct {
+		style     string
+		delimiter string
+		want      map[string]interface{}
+	}{
+		{"hugo", "+++", map[string]interface{}{
+			"title": title, "date": date, "slug": "hn20250315", "url": "/2025/03/hn20250315/",
+			"tags": []interface{}{"Hacker News"}, "categories": `日报 "AI"`,
+			"cover": map[string]interface{}{"image": "https://example.com/a.png"},
+		}},
+		{"jekyll", "---", map[string]interface{}{
+			"layout": "post", "title": title, "date": date, "slug": "hn20250315", "permalink": "/2025/03/hn20250315/",
+			"tags": []interface{}{"Hacker News"}, "categories": `日报 "AI"`, "image": "https://example.com/a.png",
+		}},
+		{"hexo", "---", map[string]interface{}{
+			"title": title, "date": date, "slug": "hn20250315", "permalink": "/2025/03/hn20250315/",
+			"tags": []interface{}{"Hacker News"}, "categories": `日报 "AI"`, "cover": "https://example.com/a.png",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			publisher := newTestMarkdownPublisher(t, tt.style)
+			digest := &models.Digest{Pid: "HN20250315", Title: title, Content: "## 正文", Cover: "https://example.com/a.png", CreatedAt: date}
+			if err := publisher.Publish(context.Background(), digest); err != nil {
+				t.Fatalf("发布失败: %v", err)
+			}
+
+			// 文件按 年/月/小写 Pid 命名
+			data, err := os.ReadFile(filepath.Join(publisher.config.Dir, "2025", "03", "hn20250315.md"))
+			if err != nil {
+				t.Fatalf("读取文章失败: %v", err)
+			}
+			parts := strings.SplitN(string(data), tt.delimiter+"\n", 3)
+			if len(parts) != 3 || parts[0] != "" || parts[2] != "\n## 正文\n" {
+				t.Fatalf("文章格式错误:\n%s", data)
+			}
+
+			got := map[string]interface{}{}
+			if tt.style == "hugo" {
+				_, err = toml.Decode(parts[1], &got)
+			} else {
+				err = yaml.Unmarshal([]byte(parts[1]), &got)
+			}
+			if err != nil {
+				t.Fatalf("解析头信息失败: %v\n%s", err, parts[1])
+			}
+			if gotDate, ok := got["date"].(time.Time); ok && gotDate.Equal(date) {
+				got["date"] = date
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("头信息 =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownIndex(t *testing.T) {
+	for _, style := range []string{"hugo", "hexo"} {
+		t.Run(style, func(t *testing.T) {
+			publisher := newTestMarkdownPublisher(t, style)
+			dir := publisher.config.Dir
+			// 没有地址字段的旧文章按 markdown.permalink 生成链接
+			writeTestFile(t, filepath.Join(dir, "2025", "02", "hn20250228.md"), "---\ntitle: \"旧文章\"\ndate: 2025-02-28 08:00:00\n---\n\n正文\n")
+
+			for _, digest := range []*models.Digest{
+				{Pid: "HN20250314", Title: "精选 [1]", CreatedAt: time.Date(2025, 3, 14, 8, 0, 0, 0, time.UTC)},
+				{Pid: "DEV20250315", Title: "精选 2", CreatedAt: time.Date(2025, 3, 15, 8, 0, 0, 0, time.UTC)},
+			} {
+				if err := publisher.Publish(context.Background(), digest); err != nil {
+					t.Fatalf("发布失败: %v", err)
+				}
+			}
+
+			indexName, delimiter := "index.md", "---\n"
+			if style == "hugo" {
+				indexName, delimiter = "_index.md", "+++\n"
+			}
+			data, err := os.ReadFile(filepath.Join(dir, indexName))
+			if err != nil {
+				t.Fatalf("读取索引页失败: %v", err)
+			}
+			// 索引页按时间倒序，链接为文章地址而不是 Markdown 文件路径
+			want := `
+## 2025-03
+
+- [精选 2](/2025/03/dev20250315/)
+- [精选 \[1\]](/2025/03/hn20250314/)
+
+## 2025-02
+
+- [旧文章](/2025/02/hn20250228/)
+`
+			if parts := strings.SplitN(string(data), delimiter, 3); len(parts) != 3 || parts[2] != want {
+				t.Errorf("索引页 =\n%s\nwant\n%s", data, want)
+			}
+		})
+	}
+}
+
+// writeTestFile 写入测试文件，自动创建目录
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
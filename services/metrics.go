@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// 文章处理结果，用于 hnai_stories_total 的 status 标签
+const (
+	StoryFetched    = "fetched"
+	StoryFiltered   = "filtered"
+	StorySummarized = "summarized"
+	StoryFailed     = "failed"
+)
+
+// Metrics Prometheus 运行指标，每个应用实例使用独立的注册表
+// 所有方法允许在 nil 上调用，未启用指标时不记录
+type Metrics struct {
+	registry     *prometheus.Registry
+	stories      *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	llmDuration  *prometheus.HistogramVec
+	llmTokens    *prometheus.CounterVec
+	llmRetries   prometheus.Counter
+	rateLimited  *prometheus.CounterVec
+	publishes    *prometheus.CounterVec
+	lastSuccess  *prometheus.GaugeVec
+}
+
+// NewMetrics 创建并注册运行指标
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		stories: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "hnai_stories_total",
+			Help: "按来源统计的文章数量，status 为 fetched、filtered、summarized 或 failed",
+		}, []string{"source", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "hnai_http_request_duration_seconds",
+			Help:    "按目标主机统计的 HTTP 请求耗时，code 为状态码，请求失败时为 error",
+			Buckets: prometheus.ExponentialBuckets(0.05, 2, 10),
+		}, []string{"host", "code"}),
+		llmDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "hnai_llm_request_duration_seconds",
+			Help:    "生成总结的 LLM 请求耗时",
+			Buckets: prometheus.ExponentialBuckets(0.25, 2, 10),
+		}, []string{"model", "result"}),
+		llmTokens: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "hnai_llm_tokens_total",
+			Help: "LLM 使用的 token 数量，direction 为 input 或 output",
+		}, []string{"model", "direction"}),
+		llmRetries: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "hnai_llm_retries_total",
+			Help: "LLM 请求重试次数",
+		}),
+		rateLimited: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "hnai_rate_limited_total",
+			Help: "遇到 429 限流的次数，service 为 gemini 或聊天平台的主机名",
+		}, []string{"service"}),
+		publishes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "hnai_publish_total",
+			Help: "按来源和发布目标统计的发布结果，result 为 success 或 failure",
+		}, []string{"source", "publisher", "result"}),
+		lastSuccess: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "hnai_last_success_timestamp_seconds",
+			Help: "来源最近一次成功运行（所有发布目标均成功）的时间戳",
+		}, []string{"source"}),
+	}
+	m.registry.MustRegister(m.stories, m.httpDuration, m.llmDuration, m.llmTokens, m.llmRetries,
+		m.rateLimited, m.publishes, m.lastSuccess,
+		collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	return m
+}
+
+// Handler 返回 /metrics 的 HTTP 处理器
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Push 推送指标到 Pushgateway 兼容的地址，用于单次运行
+func (m *Metrics) Push(ctx context.Context, url, job string) error {
+	return push.New(url, job).Gatherer(m.registry).PushContext(ctx)
+}
+
+// Stories 记录来源的文章处理数量
+func (m *Metrics) Stories(source, status string, count int) {
+	if m == nil || count == 0 {
+		return
+	}
+	m.stories.WithLabelValues(source, status).Add(float64(count))
+}
+
+// LLMRequest 记录一次 LLM 请求的耗时和 token 数量
+func (m *Metrics) LLMRequest(model string, duration time.Duration, err error, inputTokens, outputTokens int) {
+	if m == nil {
+		return
+	}
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	m.llmDuration.WithLabelValues(model, result).Observe(duration.Seconds())
+	m.llmTokens.WithLabelValues(model, "input").Add(float64(inputTokens))
+	m.llmTokens.WithLabelValues(model, "output").Add(float64(outputTokens))
+}
+
+// LLMRetry 记录一次 LLM 请求重试
+func (m *Metrics) LLMRetry() {
+	if m == nil {
+		return
+	}
+	m.llmRetries.Inc()
+}
+
+// RateLimited 记录一次 429 限流，service 为 gemini 或聊天平台的主机名
+func (m *Metrics) RateLimited(service string) {
+	if m == nil {
+		return
+	}
+	m.rateLimited.WithLabelValues(service).Inc()
+}
+
+// Published 记录发布结果，所有目标均成功时更新来源的最近成功时间
+func (m *Metrics) Published(source string, results []PublishResult, now time.Time) {
+	if m == nil {
+		return
+	}
+	success := true
+	for _, result := range results {
+		if result.Err != nil {
+			success = false
+			m.publishes.WithLabelValues(source, result.Publisher, "failure").Inc()
+		} else {
+			m.publishes.WithLabelValues(source, result.Publisher, "success").Inc()
+		}
+	}
+	if success {
+		m.lastSuccess.WithLabelValues(source).Set(float64(now.Unix()))
+	}
+}
+
+// httpRequest 记录一次 HTTP 请求的耗时
+func (m *Metrics) httpRequest(host string, code string, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.httpDuration.WithLabelValues(host, code).Observe(duration.Seconds())
+}
+
+// metricsKey ctx 中运行指标的键
+type metricsKey struct{}
+
+// WithMetrics 返回带有运行指标的 ctx，各服务通过 ctx 记录指标
+func WithMetrics(ctx context.Context, m *Metrics) context.Context {
+	return context.WithValue(ctx, metricsKey{}, m)
+}
+
+// MetricsFrom 获取 ctx 中的运行指标，未设置时返回 nil
+func MetricsFrom(ctx context.Context) *Metrics {
+	m, _ := ctx.Value(metricsKey{}).(*Metrics)
+	return m
+}
+
+// newHTTPClient 创建记录请求耗时的 HTTP 客户端，请求在 ctx 所在的链路下记录为 span
+func newHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: otelhttp.NewTransport(metricsTransport{http.DefaultTransport}),
+	}
+}
+
+// metricsTransport 按目标主机记录请求耗时，指标从请求的 ctx 中获取
+type metricsTransport struct {
+	base http.RoundTripper
+}
+
+func (t metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	MetricsFrom(req.Context()).httpRequest(req.URL.Host, code, time.Since(start))
+	return resp, err
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/hacker-news-ai/config"
+	"github.com/hacker-news-ai/database"
+	"github.com/hacker-news-ai/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Publisher 精选内容的发布目标
+type Publisher interface {
+	// Name 发布目标名称，用于日志和结果报告
+	Name() string
+	// Publish 发布一篇精选内容
+	Publish(ctx context.Context, digest *models.Digest) error
+}
+
+// PublishResult 单个发布目标的发布结果
+type PublishResult struct {
+	Publisher string
+	Err       error
+}
+
+// AnyPublished 至少一个发布目标成功时返回 true
+func AnyPublished(results []PublishResult) bool {
+	for _, result := range results {
+		if result.Err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// NewPublishers 根据配置创建指定来源的发布目标列表
+func NewPublishers(cfg *config.Config, source string, storyRepo *database.StoryRepository, digestRepo *database.DigestRepository, subscriberRepo *database.SubscriberRepository) ([]Publisher, error) {
+	names := cfg.Source(source).Publishers
+	if len(names) == 0 {
+		// 未配置时保持原有行为，只发布到论坛
+		names = []string{"forum"}
+	}
+
+	var publishers []Publisher
+	for _, name := range names {
+		switch name {
+		case "forum":
+			publishers = append(publishers, NewForumPublisher(storyRepo))
+		case "webhook":
+			if cfg.Webhook.URL == "" {
+				return nil, fmt.Errorf("来源 %s 配置了 webhook 发布，但未配置 webhook.url", source)
+			}
+			publishers = append(publishers, NewWebhookPublisher(cfg.Webhook))
+		case "markdown":
+			switch cfg.Markdown.Style {
+			case "hugo", "jekyll", "hexo":
+			default:
+				return nil, fmt.Errorf("不支持的 markdown.style: %s", cfg.Markdown.Style)
+			}
+			publishers = append(publishers, NewMarkdownPublisher(cfg.Markdown))
+		case "feed":
+			publishers = append(publishers, NewFeedPublisher(NewFeedService(cfg.Feed), digestRepo))
+		case "email":
+			if cfg.SMTP.Host == "" || cfg.SMTP.From == "" {
+				return nil, fmt.Errorf("来源 %s 配置了 email 发布，但未配置 smtp.host 或 smtp.from", source)
+			}
+			publishers = append(publishers, NewEmailPublisher(cfg.SMTP, subscriberRepo))
+		case "telegram":
+			if cfg.Chat.Telegram.BotToken == "" || cfg.Chat.Telegram.ChatID == "" {
+				return nil, fmt.Errorf("来源 %s 配置了 telegram 发布，但未配置 bot_token 或 chat_id", source)
+			}
+			publishers = append(publishers, newChatPublisher(cfg.Chat, &telegramPlatform{config: cfg.Chat.Telegram}, cfg.Chat.Telegram.Mode))
+		case "slack", "discord", "feishu", "dingtalk":
+			webhook := map[string]config.ChatWebhookConfig{
+				"slack":    cfg.Chat.Slack,
+				"discord":  cfg.Chat.Discord,
+				"feishu":   cfg.Chat.Feishu,
+				"dingtalk": cfg.Chat.DingTalk,
+			}[name]
+			if webhook.WebhookURL == "" {
+				return nil, fmt.Errorf("来源 %s 配置了 %s 发布，但未配置 webhook_url", source, name)
+			}
+			platform := map[string]chatPlatform{
+				"slack":    &slackPlatform{config: webhook},
+				"discord":  &discordPlatform{config: webhook},
+				"feishu":   &feishuPlatform{config: webhook},
+				"dingtalk": &dingTalkPlatform{config: webhook},
+			}[name]
+			publishers = append(publishers, newChatPublisher(cfg.Chat, platform, webhook.Mode))
+		default:
+			return nil, fmt.Errorf("来源 %s 配置了未知的发布目标: %s", source, name)
+		}
+	}
+
+	return publishers, nil
+}
+
+// newChatPublisher 创建聊天群发布目标，平台未单独配置推送方式时使用 chat.mode
+func newChatPublisher(cfg config.ChatConfig, platform chatPlatform, mode string) *ChatPublisher {
+	if mode == "" {
+		mode = cfg.Mode
+	}
+	return NewChatPublisher(platform, mode, cfg.ExcerptLength, cfg.DigestURL)
+}
+
+// PublishAll 依次发布到所有目标，单个目标失败不影响其他目标
+func PublishAll(ctx context.Context, publishers []Publisher, digest *models.Digest) []PublishResult {
+	results := make([]PublishResult, 0, len(publishers))
+	for _, publisher := range publishers {
+		start := time.Now()
+		publishCtx, span := StartSpan(ctx, "publish "+publisher.Name(), trace.WithAttributes(attribute.String("publisher", publisher.Name())))
+		err := publisher.Publish(publishCtx, digest)
+		EndSpan(span, err)
+		if err != nil {
+			slog.ErrorContext(ctx, "发布失败", "publisher", publisher.Name(), "pid", digest.Pid, LogDuration, time.Since(start).Milliseconds(), "error", err)
+		} else {
+			slog.InfoContext(ctx, "发布成功", "publisher", publisher.Name(), "pid", digest.Pid, LogDuration, time.Since(start).Milliseconds())
+		}
+		results = append(results, PublishResult{
+			Publisher: publisher.Name(),
+			Err:       err,
+		})
+	}
+	return results
+}
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hacker-news-ai/config"
+	"github.com/hacker-news-ai/models"
+)
+
+// redditPost Reddit 帖子 JSON 结构
+type redditPost struct {
+	ID          string  `json:"id"`
+	Subreddit   string  `json:"subreddit"`
+	Title       string  `json:"title"`
+	URL         string  `json:"url"`
+	Permalink   string  `json:"permalink"`
+	Author      string  `json:"author"`
+	Score       int     `json:"score"`
+	NumComments int     `json:"num_comments"`
+	CreatedUTC  float64 `json:"created_utc"`
+	IsSelf      bool    `json:"is_self"`
+	Selftext    string  `json:"selftext"`
+	Stickied    bool    `json:"stickied"`
+	Over18      bool    `json:"over_18"`
+}
+
+// redditComment Reddit 评论 JSON 结构，replies 没有回复时为空字符串
+type redditComment struct {
+	Author  string          `json:"author"`
+	Body    string          `json:"body"`
+	Score   int             `json:"score"`
+	Replies json.RawMessage `json:"replies"`
+}
+
+// redditListing Reddit 列表结构
+type redditListing[T any] struct {
+	Data struct {
+		Children []struct {
+			Kind string `json:"kind"`
+			Data T      `json:"data"`
+		} `json:"children"`
+	} `json:"data"`
+}
+
+type RedditService struct {
+	config    *config.Config
+	client    *http.Client
+	extractor *ContentExtractor
+}
+
+func NewRedditService(cfg *config.Config) *RedditService {
+	return &RedditService{
+		config:    cfg,
+		client:    newHTTPClient(10 * time.Second),
+		extractor: NewContentExtractor(),
+	}
+}
+
+func (s *RedditService) Name() string {
+	return "reddit"
+}
+
+// FetchTopStories 获取配置的各个 subreddit 的热门帖子
+func (s *RedditService) FetchTopStories(ctx context.Context) ([]models.Story, error) {
+	var posts []redditPost
+	for _, subreddit := range s.config.Reddit.Subreddits {
+		var listing redditListing[redditPost]
+		query := url.Values{}
+		query.Set("t", s.config.Reddit.TimeWindow)
+		query.Set("limit", strconv.Itoa(s.config.Source(s.Name()).Candidates))
+		if err := s.getJSON(ctx, fmt.Sprintf("/r/%s/top.json?%s", subreddit, query.Encode()), &listing); err != nil {
+			slog.WarnContext(ctx, "获取 subreddit 热门帖子失败", "subreddit", subreddit, "error", err)
+			continue
+		}
+		for _, child := range listing.Data.Children {
+			post := child.Data
+			if post.Stickied || post.Over18 || post.Score < s.config.Reddit.MinScore {
+				continue
+			}
+			posts = append(posts, post)
+		}
+	}
+	if len(posts) == 0 {
+		return nil, fmt.Errorf("未获取到符合条件的Reddit帖子")
+	}
+
+	// 多个 subreddit 合并后按得票排序
+	sort.Slice(posts, func(i, j int) bool {
+		return posts[i].Score > posts[j].Score
+	})
+
+	// 限制获取的文章数量
+	if len(posts) > s.config.Source(s.Name()).Candidates {
+		posts = posts[:s.config.Source(s.Name()).Candidates]
+	}
+
+	// 获取每个帖子的详细内容
+	var stories []models.Story
+	for _, post := range posts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		story, err := s.FetchStory(ctx, post.ID)
+		if err != nil {
+			slog.WarnContext(ctx, "获取Reddit帖子失败", "post_id", post.ID, "error", err)
+			continue
+		}
+		stories = append(stories, story)
+	}
+
+	return stories, nil
+}
+
+// FetchStory 获取单个帖子的详细信息和热门评论
+func (s *RedditService) FetchStory(ctx context.Context, id string) (models.Story, error) {
+	var story models.Story
+
+	// 评论接口返回两个列表：帖子本身和评论
+	var listings []json.RawMessage
+	query := url.Values{}
+	query.Set("sort", "top")
+	query.Set("depth", "3")
+	query.Set("limit", strconv.Itoa(s.config.Reddit.CommentThreads*3))
+	if err := s.getJSON(ctx, fmt.Sprintf("/comments/%s.json?%s", id, query.Encode()), &listings); err != nil {
+		return story, fmt.Errorf("获取Reddit帖子详情失败: %v", err)
+	}
+	if len(listings) < 2 {
+		return story, fmt.Errorf("Reddit帖子详情格式错误")
+	}
+
+	var postListing redditListing[redditPost]
+	if err := json.Unmarshal(listings[0], &postListing); err != nil || len(postListing.Data.Children) == 0 {
+		return story, fmt.Errorf("解析Reddit帖子详情失败: %v", err)
+	}
+	post := postListing.Data.Children[0].Data
+
+	var commentListing redditListing[redditComment]
+	if err := json.Unmarshal(listings[1], &commentListing); err != nil {
+		return story, fmt.Errorf("解析Reddit评论失败: %v", err)
+	}
+
+	discussionURL := s.config.Reddit.WebBaseURL + post.Permalink
+	article, err := s.postContent(ctx, post)
+	if err != nil {
+		return story, fmt.Errorf("获取文章内容失败: %v", err)
+	}
+	storyURL := post.URL
+	if post.IsSelf {
+		storyURL = discussionURL
+	}
+
+	// id 为 36 进制字符串，转换为整数作为稳定的文章 ID
+	storyID, err := strconv.ParseInt(post.ID, 36, 64)
+	if err != nil {
+		return story, fmt.Errorf("解析Reddit帖子ID失败: %v", err)
+	}
+
+	story = models.Story{
+		ID:            int(storyID),
+		Title:         post.Title,
+		URL:           storyURL,
+		DiscussionURL: discussionURL,
+		Score:         post.Score,
+		Time:          time.Unix(int64(post.CreatedUTC), 0),
+		By:            post.Author,
+		Descendants:   post.NumComments,
+		Tags:          []string{"r/" + post.Subreddit},
+		Content:       buildContent(article, s.formatComments(commentListing)),
+	}
+
+	return story, nil
+}
+
+// postContent 文本帖使用帖子正文，链接帖提取原文，图片和视频帖只保留标题和链接
+func (s *RedditService) postContent(ctx context.Context, post redditPost) (string, error) {
+	if post.IsSelf {
+		return post.Selftext, nil
+	}
+
+	host := ""
+	if u, err := url.Parse(post.URL); err == nil {
+		host = u.Hostname()
+	}
+	switch host {
+	case "i.redd.it", "v.redd.it", "i.imgur.com":
+		return fmt.Sprintf("%s\n\n%s", post.Title, post.URL), nil
+	}
+
+	article, err := s.extractor.Extract(ctx, post.URL)
+	if err != nil {
+		return "", err
+	}
+	if post.Selftext != "" {
+		article = fmt.Sprintf("发帖人说明：%s\n\n%s", post.Selftext, article)
+	}
+	return article, nil
+}
+
+// formatComments 格式化前若干个热门讨论串，每个讨论串保留得票最高的两层回复
+func (s *RedditService) formatComments(listing redditListing[redditComment]) string {
+	var comments []string
+	var walk func(comment redditComment, depth int)
+	walk = func(comment redditComment, depth int) {
+		if comment.Body == "" || comment.Body == "[deleted]" || comment.Body == "[removed]" {
+			return
+		}
+		indent := strings.Repeat("  ", depth)
+		text := strings.ReplaceAll(comment.Body, "\n", "\n"+indent)
+		comments = append(comments, fmt.Sprintf("%s@%s (得分:%d): %s", indent, comment.Author, comment.Score, text))
+		if depth >= 2 || len(comment.Replies) == 0 || comment.Replies[0] != '{' {
+			return
+		}
+		var replies redditListing[redditComment]
+		if err := json.Unmarshal(comment.Replies, &replies); err != nil {
+			return
+		}
+		for _, child := range replies.Data.Children {
+			if child.Kind == "t1" {
+				walk(child.Data, depth+1)
+				break
+			}
+		}
+	}
+
+	threads := 0
+	for _, child := range listing.Data.Children {
+		if child.Kind != "t1" {
+			continue
+		}
+		if threads >= s.config.Reddit.CommentThreads {
+			break
+		}
+		walk(child.Data, 0)
+		threads++
+	}
+	return strings.Join(comments, "\n")
+}
+
+// getJSON 请求 Reddit JSON 接口，Reddit 要求设置自定义 User-Agent
+func (s *RedditService) getJSON(ctx context.Context, path string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.config.Reddit.APIBaseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", s.config.Reddit.UserAgent)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("请求失败: %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hacker-news-ai/config"
+	"github.com/hacker-news-ai/models"
+)
+
+func TestRedditFetchStory(t *testing.T) {
+	baseURL := newFixtureServer(t, map[string]string{
+		"/comments/1abcd2.json": "testdata/reddit_comments.json",
+		"/comments/1xyz9.json":  "testdata/reddit_image.json",
+	})
+	cfg := &config.Config{Reddit: config.RedditConfig{APIBaseURL: baseURL, WebBaseURL: "https://old.reddit.example", CommentThreads: 3}}
+	service := NewRedditService(cfg)
+	created := time.Unix(1742025600, 0)
+
+	tests := []struct {
+		id   string
+		want models.Story
+	}{
+		// 文本帖链接到讨论页，讨论页使用 web_base_url 和 permalink
+		// 每个讨论串只保留得票最高的两层回复，已删除的评论占用讨论串数量
+		{"1abcd2", models.Story{
+			ID:            77791574,
+			Title:         "What's new in Go 1.24?",
+			URL:           "https://old.reddit.example/r/golang/comments/1abcd2/whats_new_in_go_124/",
+			DiscussionURL: "https://old.reddit.example/r/golang/comments/1abcd2/whats_new_in_go_124/",
+			Score:         321,
+			Time:          created,
+			By:            "gopher",
+			Descendants:   45,
+			Tags:          []string{"r/golang"},
+			Content: "\n<article>\nGeneric type aliases are finally here.\n</article>\n\n---\n\n<comments>\n" +
+				"@alice (得分:120): Swiss tables!\n" +
+				"  @bob (得分:60): Maps got faster\n  across the board.\n" +
+				"    @carol (得分:20): Benchmarks?\n" +
+				"@frank (得分:40): Tool directives in go.mod.\n" +
+				"</comments>\n",
+		}},
+		// 图片帖不提取原文，只保留标题和链接
+		{"1xyz9", models.Story{
+			ID:            3264597,
+			Title:         "Diagram of the Go scheduler",
+			URL:           "https://i.redd.it/scheduler.png",
+			DiscussionURL: "https://old.reddit.example/r/programming/comments/1xyz9/diagram/",
+			Score:         88,
+			Time:          created,
+			By:            "drawer",
+			Tags:          []string{"r/programming"},
+			Content:       "\n<article>\nDiagram of the Go scheduler\n\nhttps://i.redd.it/scheduler.png\n</article>\n",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			story, err := service.FetchStory(context.Background(), tt.id)
+			if err != nil {
+				t.Fatalf("获取帖子失败: %v", err)
+			}
+			if !reflect.DeepEqual(story, tt.want) {
+				t.Errorf("帖子 =\n%+v\nwant\n%+v", story, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hacker-news-ai/models"
+)
+
+// Report 收集一个来源一次运行的文章数量、失败原因和 token 用量
+// 所有方法允许在 nil 上调用，未收集报告时不记录
+type Report struct {
+	mu     sync.Mutex
+	report models.RunReport
+}
+
+// NewReport 创建来源一次运行的报告
+func NewReport(runID, source string, start time.Time) *Report {
+	return &Report{report: models.RunReport{
+		RunID:     runID,
+		Source:    source,
+		StartedAt: start,
+	}}
+}
+
+// Stories 记录文章处理数量，status 与 hnai_stories_total 相同
+func (r *Report) Stories(status string, count int) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch status {
+	case StoryFetched:
+		r.report.Fetched += count
+	case StoryFiltered:
+		r.report.Filtered += count
+	case StorySummarized:
+		r.report.Summarized += count
+	case StoryFailed:
+		r.report.Failed += count
+	}
+}
+
+// Fail 记录文章获取内容或生成总结失败的原因
+func (r *Report) Fail(stage string, story models.Story, err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.report.Failures = append(r.report.Failures, models.RunFailure{
+		Stage:   stage,
+		StoryID: story.ID,
+		Title:   story.Title,
+		Reason:  err.Error(),
+	})
+}
+
+// Tokens 记录一次 LLM 请求使用的 token 数量
+func (r *Report) Tokens(inputTokens, outputTokens int) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.report.InputTokens += inputTokens
+	r.report.OutputTokens += outputTokens
+}
+
+// Published 记录发布结果，至少一个目标成功时视为已发布
+func (r *Report) Published(pid string, results []PublishResult) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.report.Pid = pid
+	for _, result := range results {
+		if result.Err == nil {
+			r.report.Published = true
+			continue
+		}
+		r.report.Failures = append(r.report.Failures, models.RunFailure{
+			Stage:     "publish",
+			Publisher: result.Publisher,
+			Reason:    result.Err.Error(),
+		})
+	}
+}
+
+// Finish 结束运行并返回报告，err 为导致运行提前结束的错误
+func (r *Report) Finish(err error, now time.Time) *models.RunReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	report := r.report
+	report.DurationMs = now.Sub(report.StartedAt).Milliseconds()
+	if err != nil {
+		report.Error = err.Error()
+	}
+	return &report
+}
+
+// reportKey ctx 中运行报告的键
+type reportKey struct{}
+
+// WithReport 返回带有运行报告的 ctx，各服务通过 ctx 记录 token 用量
+func WithReport(ctx context.Context, r *Report) context.Context {
+	return context.WithValue(ctx, reportKey{}, r)
+}
+
+// ReportFrom 获取 ctx 中的运行报告，未设置时返回 nil
+func ReportFrom(ctx context.Context) *Report {
+	r, _ := ctx.Value(reportKey{}).(*Report)
+	return r
+}
@@ -0,0 +1,440 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hacker-news-ai/config"
+	"github.com/hacker-news-ai/models"
+	"golang.org/x/net/html/charset"
+)
+
+// maxSeenItems 每个订阅源记录的已读条目上限
+const maxSeenItems = 500
+
+// htmlTagPattern 用于去除订阅摘要中的 HTML 标签
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// feedDocument 兼容 RSS 2.0、RSS 1.0 和 Atom 的订阅文档
+type feedDocument struct {
+	Channel struct {
+		Items []feedDocItem `xml:"item"`
+	} `xml:"channel"`
+	// RSS 1.0 的条目位于根节点下
+	Items   []feedDocItem `xml:"item"`
+	Entries []struct {
+		ID    string `xml:"id"`
+		Title string `xml:"title"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Published string `xml:"published"`
+		Updated   string `xml:"updated"`
+		Summary   string `xml:"summary"`
+		Content   string `xml:"content"`
+		Author    struct {
+			Name string `xml:"name"`
+		} `xml:"author"`
+	} `xml:"entry"`
+}
+
+// feedDocItem RSS 条目
+type feedDocItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	PubDate     string `xml:"pubDate"`
+	Date        string `xml:"date"`
+	Description string `xml:"description"`
+	Encoded     string `xml:"encoded"`
+	Creator     string `xml:"creator"`
+	Author      string `xml:"author"`
+}
+
+// feedEntry 统一格式的订阅条目
+type feedEntry struct {
+	feed      string
+	feedURL   string
+	id        string
+	title     string
+	link      string
+	author    string
+	summary   string
+	published time.Time
+}
+
+// rssFeedState 订阅源的抓取状态
+type rssFeedState struct {
+	ETag         string   `json:"etag"`
+	LastModified string   `json:"last_modified"`
+	Seen         []string `json:"seen"`
+}
+
+// rssPending 本次运行获取但尚未确认的订阅状态，发布成功后由 Commit 写入状态文件
+type rssPending struct {
+	// 各订阅源响应中的 ETag 和 Last-Modified
+	validators map[string]rssFeedState
+	// 各订阅源本次获取到的全部未读条目，包括因数量限制未返回的条目
+	unseen map[string][]string
+	// 返回的文章ID对应的订阅条目
+	stories map[int]feedEntry
+}
+
+type RSSService struct {
+	config    *config.Config
+	client    *http.Client
+	extractor *ContentExtractor
+
+	mu      sync.Mutex
+	pending *rssPending
+}
+
+func NewRSSService(cfg *config.Config) *RSSService {
+	return &RSSService{
+		config:    cfg,
+		client:    newHTTPClient(10 * time.Second),
+		extractor: NewContentExtractor(),
+	}
+}
+
+func (s *RSSService) Name() string {
+	return "rss"
+}
+
+// FetchTopStories 获取所有订阅源中未读过的文章，按发布时间倒序
+// 获取时不标记已读，发布成功后通过 Commit 标记，失败时下次运行仍会获取这些文章
+func (s *RSSService) FetchTopStories(ctx context.Context) ([]models.Story, error) {
+	state, err := s.loadState()
+	if err != nil {
+		return nil, err
+	}
+
+	pending := &rssPending{
+		validators: make(map[string]rssFeedState),
+		unseen:     make(map[string][]string),
+		stories:    make(map[int]feedEntry),
+	}
+	var entries []feedEntry
+	settled := false
+	for _, feed := range s.config.RSS.Feeds {
+		feedState := state[feed.URL]
+		if feedState == nil {
+			feedState = &rssFeedState{}
+			state[feed.URL] = feedState
+		}
+		items, validators, err := s.fetchFeed(ctx, feed, feedState)
+		if err != nil {
+			slog.WarnContext(ctx, "获取订阅源失败", "feed", feed.Name, "error", err)
+			continue
+		}
+		if validators == nil {
+			continue
+		}
+		if len(items) == 0 {
+			// 没有未读条目，可以直接保存缓存标识
+			feedState.ETag, feedState.LastModified = validators.ETag, validators.LastModified
+			settled = true
+			continue
+		}
+		pending.validators[feed.URL] = *validators
+		for _, item := range items {
+			pending.unseen[feed.URL] = append(pending.unseen[feed.URL], item.id)
+		}
+		entries = append(entries, items...)
+	}
+	if settled {
+		if err := s.saveState(state); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].published.After(entries[j].published)
+	})
+
+	// 限制获取的文章数量，未返回的条目仍为未读，对应订阅源的缓存标识暂不保存
+	if len(entries) > s.config.Source(s.Name()).Candidates {
+		entries = entries[:s.config.Source(s.Name()).Candidates]
+	}
+
+	var stories []models.Story
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		story := s.buildStory(ctx, entry)
+		pending.stories[story.ID] = entry
+		stories = append(stories, story)
+	}
+
+	s.mu.Lock()
+	s.pending = pending
+	s.mu.Unlock()
+	return stories, nil
+}
+
+// Commit 将已发布的文章标记为已读，订阅源没有剩余未读条目时保存其 ETag 和 Last-Modified
+// 未发布的文章保持未读，且对应订阅源下次仍会完整下载，不会因 304 响应而漏掉
+func (s *RSSService) Commit(ctx context.Context, stories []models.Story) error {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+	if pending == nil {
+		return nil
+	}
+
+	state, err := s.loadState()
+	if err != nil {
+		return err
+	}
+	for _, story := range stories {
+		entry, ok := pending.stories[story.ID]
+		if !ok {
+			continue
+		}
+		feedState := state[entry.feedURL]
+		if feedState == nil {
+			feedState = &rssFeedState{}
+			state[entry.feedURL] = feedState
+		}
+		if !slices.Contains(feedState.Seen, entry.id) {
+			markSeen(feedState, entry.id)
+		}
+	}
+
+	for feedURL, validators := range pending.validators {
+		feedState := state[feedURL]
+		if feedState == nil {
+			continue
+		}
+		remaining := 0
+		for _, id := range pending.unseen[feedURL] {
+			if !slices.Contains(feedState.Seen, id) {
+				remaining++
+			}
+		}
+		if remaining > 0 {
+			slog.DebugContext(ctx, "订阅源仍有未读条目，暂不保存缓存标识", "feed", feedURL, "unseen", remaining)
+			continue
+		}
+		feedState.ETag, feedState.LastModified = validators.ETag, validators.LastModified
+	}
+	return s.saveState(state)
+}
+
+// fetchFeed 使用 ETag/Last-Modified 条件请求获取订阅源，返回未读条目和响应中的缓存标识
+// 未更新时缓存标识为 nil，缓存标识由调用方在条目全部已读后保存
+func (s *RSSService) fetchFeed(ctx context.Context, feed config.RSSFeed, state *rssFeedState) ([]feedEntry, *rssFeedState, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", feed.URL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("User-Agent", "hacker-news-ai/1.0")
+	if state.ETag != "" {
+		req.Header.Set("If-None-Match", state.ETag)
+	}
+	if state.LastModified != "" {
+		req.Header.Set("If-Modified-Since", state.LastModified)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("请求失败: %s", resp.Status)
+	}
+
+	var doc feedDocument
+	decoder := xml.NewDecoder(resp.Body)
+	decoder.CharsetReader = charset.NewReaderLabel
+	decoder.Strict = false
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, nil, fmt.Errorf("解析订阅失败: %v", err)
+	}
+	validators := &rssFeedState{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+
+	seen := make(map[string]bool, len(state.Seen))
+	for _, id := range state.Seen {
+		seen[id] = true
+	}
+	maxAge := time.Duration(s.config.RSS.MaxAgeHours) * time.Hour
+
+	var entries []feedEntry
+	for _, entry := range parseFeedEntries(feed, doc) {
+		if entry.id == "" || seen[entry.id] {
+			continue
+		}
+		if maxAge > 0 && !entry.published.IsZero() && time.Since(entry.published) > maxAge {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, validators, nil
+}
+
+// parseFeedEntries 将 RSS 和 Atom 条目转换为统一格式
+func parseFeedEntries(feed config.RSSFeed, doc feedDocument) []feedEntry {
+	feedName := feed.Name
+	var entries []feedEntry
+	for _, item := range append(doc.Channel.Items, doc.Items...) {
+		entry := feedEntry{
+			feed:      feedName,
+			feedURL:   feed.URL,
+			id:        strings.TrimSpace(item.GUID),
+			title:     strings.TrimSpace(item.Title),
+			link:      strings.TrimSpace(item.Link),
+			author:    firstNonEmpty(item.Creator, item.Author, feedName),
+			summary:   firstNonEmpty(item.Encoded, item.Description),
+			published: parseFeedTime(firstNonEmpty(item.PubDate, item.Date)),
+		}
+		if entry.id == "" {
+			entry.id = entry.link
+		}
+		entries = append(entries, entry)
+	}
+
+	for _, item := range doc.Entries {
+		entry := feedEntry{
+			feed:      feedName,
+			feedURL:   feed.URL,
+			id:        strings.TrimSpace(item.ID),
+			title:     strings.TrimSpace(item.Title),
+			author:    firstNonEmpty(item.Author.Name, feedName),
+			summary:   firstNonEmpty(item.Content, item.Summary),
+			published: parseFeedTime(firstNonEmpty(item.Published, item.Updated)),
+		}
+		for _, link := range item.Links {
+			if link.Rel == "" || link.Rel == "alternate" {
+				entry.link = link.Href
+				break
+			}
+		}
+		if entry.id == "" {
+			entry.id = entry.link
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// buildStory 提取原文全文，失败时使用订阅中的摘要
+func (s *RSSService) buildStory(ctx context.Context, entry feedEntry) models.Story {
+	article, err := s.extractor.Extract(ctx, entry.link)
+	if err != nil {
+		slog.WarnContext(ctx, "提取全文失败，使用订阅摘要", "url", entry.link, "error", err)
+		article = strings.TrimSpace(html.UnescapeString(htmlTagPattern.ReplaceAllString(entry.summary, "")))
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(entry.id))
+
+	published := entry.published
+	if published.IsZero() {
+		published = time.Now()
+	}
+
+	return models.Story{
+		ID:      int(hash.Sum32()),
+		Title:   entry.title,
+		URL:     entry.link,
+		Time:    published,
+		By:      entry.author,
+		Tags:    []string{entry.feed},
+		Content: buildContent(article, ""),
+	}
+}
+
+// loadState 读取订阅源抓取状态，文件不存在时返回空状态
+func (s *RSSService) loadState() (map[string]*rssFeedState, error) {
+	state := make(map[string]*rssFeedState)
+	data, err := os.ReadFile(s.config.RSS.StatePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取订阅状态失败: %v", err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("解析订阅状态失败: %v", err)
+	}
+	return state, nil
+}
+
+// saveState 保存订阅源抓取状态
+func (s *RSSService) saveState(state map[string]*rssFeedState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化订阅状态失败: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.config.RSS.StatePath), 0755); err != nil {
+		return fmt.Errorf("创建订阅状态目录失败: %v", err)
+	}
+	if err := os.WriteFile(s.config.RSS.StatePath, data, 0644); err != nil {
+		return fmt.Errorf("保存订阅状态失败: %v", err)
+	}
+	return nil
+}
+
+// markSeen 记录已读条目，超过上限时丢弃最早的记录
+func markSeen(state *rssFeedState, id string) {
+	state.Seen = append(state.Seen, id)
+	if len(state.Seen) > maxSeenItems {
+		state.Seen = state.Seen[len(state.Seen)-maxSeenItems:]
+	}
+}
+
+// parseFeedTime 解析订阅中常见的时间格式
+func parseFeedTime(value string) time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{
+		time.RFC1123Z,
+		time.RFC1123,
+		time.RFC3339,
+		"Mon, 2 Jan 2006 15:04:05 -0700",
+		"Mon, 2 Jan 2006 15:04:05 MST",
+		"2 Jan 2006 15:04:05 -0700",
+		"2006-01-02T15:04:05",
+		"2006-01-02",
+	} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// firstNonEmpty 返回第一个非空字符串
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
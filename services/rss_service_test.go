@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/hacker-news-ai/config"
+	"github.com/hacker-news-ai/models"
+)
+
+// fakeFeedServer 提供带 ETag 的订阅源，记录条件请求的次数
+type fakeFeedServer struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	notModified int
+}
+
+func newFakeFeedServer(t *testing.T) *fakeFeedServer {
+	t.Helper()
+	s := &fakeFeedServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			s.mu.Lock()
+			s.notModified++
+			s.mu.Unlock()
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprintf(w, `<rss><channel>
+<item><guid>a</guid><title>A</title><link>%[1]s/a</link><pubDate>Sat, 15 Mar 2025 10:00:00 +0000</pubDate></item>
+<item><guid>b</guid><title>B</title><link>%[1]s/b</link><pubDate>Sat, 15 Mar 2025 09:00:00 +0000</pubDate></item>
+</channel></rss>`, s.URL)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestRSSCommitAfterPublish(t *testing.T) {
+	server := newFakeFeedServer(t)
+	statePath := filepath.Join(t.TempDir(), "rss_state.json")
+	cfg := &config.Config{
+		RSS: config.RSSConfig{
+			Feeds:     []config.RSSFeed{{Name: "test", URL: server.URL + "/feed"}},
+			StatePath: statePath,
+		},
+		Sources: map[string]config.SourceConfig{"rss": {Limit: 1, Candidates: 1}},
+	}
+	ctx := context.Background()
+
+	fetch := func(s *RSSService, want string) []models.Story {
+		t.Helper()
+		stories, err := s.FetchTopStories(ctx)
+		if err != nil {
+			t.Fatalf("获取失败: %v", err)
+		}
+		if len(stories) != 1 || stories[0].Title != want {
+			t.Fatalf("获取到 %v, want %s", stories, want)
+		}
+		return stories
+	}
+
+	// 发布失败不调用 Commit，下次运行仍获取同一篇文章
+	fetch(NewRSSService(cfg), "A")
+	fetch(NewRSSService(cfg), "A")
+
+	// A 发布成功后标记已读，B 因数量限制未返回，不保存 ETag
+	s := NewRSSService(cfg)
+	stories := fetch(s, "A")
+	if err := s.Commit(ctx, stories); err != nil {
+		t.Fatalf("确认失败: %v", err)
+	}
+	state, err := s.loadState()
+	if err != nil {
+		t.Fatalf("读取状态失败: %v", err)
+	}
+	feedState := state[server.URL+"/feed"]
+	if feedState.ETag != "" {
+		t.Errorf("仍有未读条目时保存了 ETag %q", feedState.ETag)
+	}
+
+	// 下次运行完整下载订阅源，获取到 B
+	s = NewRSSService(cfg)
+	stories = fetch(s, "B")
+	if err := s.Commit(ctx, stories); err != nil {
+		t.Fatalf("确认失败: %v", err)
+	}
+	state, _ = s.loadState()
+	if got := state[server.URL+"/feed"].ETag; got != `"v1"` {
+		t.Errorf("条目全部已读后 ETag = %q, want %q", got, `"v1"`)
+	}
+
+	// 全部已读后使用条件请求
+	s = NewRSSService(cfg)
+	if stories, err := s.FetchTopStories(ctx); err != nil || len(stories) != 0 {
+		t.Fatalf("获取到 %v, %v, want 无新文章", stories, err)
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.notModified != 1 {
+		t.Errorf("条件请求命中 %d 次, want 1", server.notModified)
+	}
+}
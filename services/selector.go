@@ -0,0 +1,251 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/hacker-news-ai/config"
+	"github.com/hacker-news-ai/database"
+	"github.com/hacker-news-ai/models"
+)
+
+// topicStopWords 计算话题相似度时忽略的常见词
+var topicStopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "from": true, "that": true, "this": true,
+	"your": true, "you": true, "are": true, "how": true, "why": true, "what": true, "who": true,
+	"new": true, "not": true, "can": true, "now": true, "its": true, "into": true, "about": true,
+	"show": true, "ask": true, "tell": true, "launch": true, "pdf": true, "video": true,
+}
+
+// CandidateSource 支持先获取候选文章元数据，排序选定后再获取正文的来源
+type CandidateSource interface {
+	Source
+	// FetchCandidates 获取候选文章的元数据，不包含正文
+	FetchCandidates(ctx context.Context, limit int) ([]models.Story, error)
+	// FetchContent 获取选中文章的正文和评论
+	FetchContent(ctx context.Context, story *models.Story) error
+}
+
+// selectionStore 精选历史的读写，默认为 DigestRepository
+type selectionStore interface {
+	SaveSelection(ctx context.Context, history *models.SelectionHistory) error
+	ListSelectionsSince(ctx context.Context, source string, since time.Time) ([]models.SelectionHistory, error)
+}
+
+// StorySelector 按权重为候选文章打分，选出本期精选的文章
+type StorySelector struct {
+	config     config.SelectionConfig
+	enabled    bool
+	limit      int
+	candidates int
+	store      selectionStore
+}
+
+// NewStorySelector 创建指定来源的排序器，未配置排序时保持原始顺序
+func NewStorySelector(cfg *config.Config, source string, digestRepo *database.DigestRepository) *StorySelector {
+	sourceConfig := cfg.Source(source)
+	selector := &StorySelector{
+		enabled:    sourceConfig.Selection != nil,
+		limit:      sourceConfig.Limit,
+		candidates: sourceConfig.Candidates,
+		store:      digestRepo,
+	}
+	if selector.enabled {
+		selector.config = *sourceConfig.Selection
+	}
+	if selector.config.NoveltyDays == 0 {
+		selector.config.NoveltyDays = 7
+	}
+	return selector
+}
+
+// Candidates 返回需要获取的候选文章数量
+func (s *StorySelector) Candidates() int {
+	return s.candidates
+}
+
+// selectionTopics 近期精选中一篇文章的话题词，weight 随精选的时间从 1 线性衰减到 0
+type selectionTopics struct {
+	topics map[string]bool
+	weight float64
+}
+
+// selectionCandidate 候选文章及其话题词和基础得分
+type selectionCandidate struct {
+	story  models.Story
+	topics map[string]bool
+	base   float64
+}
+
+// Select 选出本期精选的文章：先按评分、评论速度、新鲜度和新颖度计算基础得分，
+// 再逐篇挑选，与已选文章话题相似的候选会按多样性权重扣分
+func (s *StorySelector) Select(ctx context.Context, source string, stories []models.Story, now time.Time) []models.Story {
+	if !s.enabled {
+		if len(stories) > s.limit {
+			stories = stories[:s.limit]
+		}
+		return stories
+	}
+
+	weights := s.config.Weights
+	history, seen := s.history(ctx, source, now)
+
+	var maxScore, maxVelocity float64
+	candidates := make([]selectionCandidate, len(stories))
+	velocities := make([]float64, len(stories))
+	for i, story := range stories {
+		candidates[i] = selectionCandidate{story: story, topics: storyTopics(story)}
+		velocities[i] = float64(story.Descendants) / math.Max(now.Sub(story.Time).Hours(), 1)
+		maxScore = math.Max(maxScore, math.Log1p(float64(story.Score)))
+		maxVelocity = math.Max(maxVelocity, velocities[i])
+	}
+
+	for i := range candidates {
+		story := candidates[i].story
+		var base float64
+		if maxScore > 0 {
+			base += weights.Score * math.Log1p(float64(story.Score)) / maxScore
+		}
+		if maxVelocity > 0 {
+			base += weights.Velocity * velocities[i] / maxVelocity
+		}
+		// 新鲜度按一天为半衰期衰减
+		base += weights.Freshness * math.Pow(0.5, math.Max(now.Sub(story.Time).Hours(), 0)/24)
+		if weights.Novelty > 0 {
+			novelty := 1.0
+			if seen[story.ID] {
+				novelty = 0
+			} else {
+				for _, previous := range history {
+					novelty = math.Min(novelty, 1-previous.weight*topicSimilarity(candidates[i].topics, previous.topics))
+				}
+			}
+			base += weights.Novelty * novelty
+		}
+		candidates[i].base = base
+	}
+
+	// 基础得分相同时保持来源的原始顺序
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].base > candidates[j].base
+	})
+
+	var selected []selectionCandidate
+	for len(selected) < s.limit && len(candidates) > 0 {
+		best, bestScore := 0, math.Inf(-1)
+		for i, candidate := range candidates {
+			score := candidate.base
+			for _, chosen := range selected {
+				score = math.Min(score, candidate.base-weights.Diversity*topicSimilarity(candidate.topics, chosen.topics))
+			}
+			if score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		slog.InfoContext(ctx, "文章入选", LogStoryID, candidates[best].story.ID, "title", candidates[best].story.Title, "score", bestScore)
+		selected = append(selected, candidates[best])
+		candidates = append(candidates[:best], candidates[best+1:]...)
+	}
+
+	result := make([]models.Story, len(selected))
+	for i, candidate := range selected {
+		result[i] = candidate.story
+	}
+	return result
+}
+
+// Record 保存已发布的精选，供之后的排序判断新颖度，只在至少一个发布目标成功后调用
+func (s *StorySelector) Record(ctx context.Context, digest *models.Digest) {
+	if !s.enabled || s.config.Weights.Novelty == 0 {
+		return
+	}
+	// 只需要计算话题词的字段，不保存正文和总结
+	stories := make([]models.Story, len(digest.Stories))
+	for i, story := range digest.Stories {
+		stories[i] = models.Story{ID: story.ID, Title: story.Title, URL: story.URL, Tags: story.Tags}
+	}
+	history := &models.SelectionHistory{
+		Source:    digest.Source,
+		Pid:       digest.Pid,
+		Stories:   stories,
+		CreatedAt: digest.CreatedAt,
+	}
+	if err := s.store.SaveSelection(ctx, history); err != nil {
+		slog.ErrorContext(ctx, "保存精选历史失败", "pid", digest.Pid, "error", err)
+	}
+}
+
+// history 返回最近 novelty_days 天精选中文章的话题词和文章ID
+// 越早的精选权重越低，其中已收录的文章新颖度为 0，超过 novelty_days 后不再影响排序
+func (s *StorySelector) history(ctx context.Context, source string, now time.Time) ([]selectionTopics, map[int]bool) {
+	seen := make(map[int]bool)
+	if s.config.Weights.Novelty == 0 {
+		return nil, seen
+	}
+	window := time.Duration(s.config.NoveltyDays) * 24 * time.Hour
+	histories, err := s.store.ListSelectionsSince(ctx, source, now.Add(-window))
+	if err != nil {
+		slog.ErrorContext(ctx, "获取精选历史失败", "error", err)
+		return nil, seen
+	}
+
+	var history []selectionTopics
+	for _, previous := range histories {
+		weight := 1 - math.Max(now.Sub(previous.CreatedAt).Hours(), 0)/window.Hours()
+		for _, story := range previous.Stories {
+			seen[story.ID] = true
+			history = append(history, selectionTopics{topics: storyTopics(story), weight: math.Max(weight, 0)})
+		}
+	}
+	return history, seen
+}
+
+// storyTopics 提取文章的话题词：标题中的单词、标签和域名
+func storyTopics(story models.Story) map[string]bool {
+	topics := make(map[string]bool)
+	words := strings.FieldsFunc(strings.ToLower(story.Title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '+' && r != '#'
+	})
+	for _, word := range words {
+		if len([]rune(word)) >= 3 && !topicStopWords[word] {
+			topics[word] = true
+		}
+	}
+	for _, tag := range story.Tags {
+		topics["tag:"+strings.ToLower(tag)] = true
+	}
+	if host := storyHost(story.URL); host != "" && !codeHosts[host] {
+		topics["host:"+host] = true
+	}
+	return topics
+}
+
+// codeHosts 代码托管网站，不同项目共用同一域名，不作为话题词
+var codeHosts = map[string]bool{
+	"github.com":    true,
+	"gitlab.com":    true,
+	"codeberg.org":  true,
+	"bitbucket.org": true,
+	"git.sr.ht":     true,
+	"sr.ht":         true,
+}
+
+// topicSimilarity 计算两组话题词的重叠系数（共同词数 / 较少一方的词数），
+// 比 Jaccard 更适合识别标题长短不一的同一话题
+func topicSimilarity(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for topic := range a {
+		if b[topic] {
+			shared++
+		}
+	}
+	return float64(shared) / math.Min(float64(len(a)), float64(len(b)))
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hacker-news-ai/config"
+	"github.com/hacker-news-ai/models"
+)
+
+// selectorNow 排序测试使用的固定时间
+var selectorNow = time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+
+// fakeSelectionStore 内存中的精选历史
+type fakeSelectionStore struct {
+	histories []models.SelectionHistory
+	saved     []*models.SelectionHistory
+}
+
+func (s *fakeSelectionStore) SaveSelection(ctx context.Context, history *models.SelectionHistory) error {
+	s.saved = append(s.saved, history)
+	return nil
+}
+
+func (s *fakeSelectionStore) ListSelectionsSince(ctx context.Context, source string, since time.Time) ([]models.SelectionHistory, error) {
+	var histories []models.SelectionHistory
+	for _, history := range s.histories {
+		if history.Source == source && !history.CreatedAt.Before(since) {
+			histories = append(histories, history)
+		}
+	}
+	return histories, nil
+}
+
+// newTestSelector 创建 hn 来源的排序器，weights 为 nil 时不配置排序
+func newTestSelector(weights *config.SelectionWeights, limit int, store *fakeSelectionStore) *StorySelector {
+	cfg := &config.Config{Sources: map[string]config.SourceConfig{"hn": {Limit: limit}}}
+	if weights != nil {
+		cfg.Selection = map[string]config.SelectionConfig{"hn": {Weights: *weights}}
+	}
+	selector := NewStorySelector(cfg, "hn", nil)
+	if store == nil {
+		store = &fakeSelectionStore{}
+	}
+	selector.store = store
+	return selector
+}
+
+// selectedTitles 返回选中文章的标题
+func selectedTitles(stories []models.Story) []string {
+	titles := make([]string, len(stories))
+	for i, story := range stories {
+		titles[i] = story.Title
+	}
+	return titles
+}
+
+func TestSelectScoring(t *testing.T) {
+	hoursAgo := func(h float64) time.Time { return selectorNow.Add(-time.Duration(h * float64(time.Hour))) }
+	tests := []struct {
+		name    string
+		weights *config.SelectionWeights
+		stories []models.Story
+		want    []string
+	}{
+		{"disabled keeps source order", nil, []models.Story{
+			{ID: 1, Title: "a", Score: 10},
+			{ID: 2, Title: "b", Score: 500},
+			{ID: 3, Title: "c", Score: 100},
+		}, []string{"a", "b"}},
+		{"score", &config.SelectionWeights{Score: 1}, []models.Story{
+			{ID: 1, Title: "a", Score: 10, Time: hoursAgo(1)},
+			{ID: 2, Title: "b", Score: 500, Time: hoursAgo(1)},
+			{ID: 3, Title: "c", Score: 100, Time: hoursAgo(1)},
+		}, []string{"b", "c"}},
+		{"velocity", &config.SelectionWeights{Velocity: 1}, []models.Story{
+			// 每小时 10 条评论
+			{ID: 1, Title: "a", Descendants: 100, Time: hoursAgo(10)},
+			// 每小时 50 条评论
+			{ID: 2, Title: "b", Descendants: 50, Time: hoursAgo(1)},
+			// 发布不到一小时按一小时计算
+			{ID: 3, Title: "c", Descendants: 20, Time: hoursAgo(0.1)},
+		}, []string{"b", "c"}},
+		{"freshness", &config.SelectionWeights{Freshness: 1}, []models.Story{
+			{ID: 1, Title: "a", Time: hoursAgo(48)},
+			{ID: 2, Title: "b", Time: hoursAgo(1)},
+			{ID: 3, Title: "c", Time: hoursAgo(24)},
+		}, []string{"b", "c"}},
+		{"equal scores keep source order", &config.SelectionWeights{Score: 1}, []models.Story{
+			{ID: 1, Title: "a", Score: 100, Time: hoursAgo(1)},
+			{ID: 2, Title: "b", Score: 100, Time: hoursAgo(1)},
+			{ID: 3, Title: "c", Score: 100, Time: hoursAgo(1)},
+		}, []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selector := newTestSelector(tt.weights, 2, nil)
+			got := selectedTitles(selector.Select(context.Background(), "hn", tt.stories, selectorNow))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Select() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectDiversityPenalty(t *testing.T) {
+	stories := []models.Story{
+		{ID: 1, Title: "Rust async runtime", Score: 300, URL: "https://a.example/1", Time: selectorNow},
+		{ID: 2, Title: "Rust async runtime internals", Score: 290, URL: "https://b.example/2", Time: selectorNow},
+		{ID: 3, Title: "Postgres indexes", Score: 100, URL: "https://c.example/3", Time: selectorNow},
+	}
+	tests := []struct {
+		name      string
+		diversity float64
+		want      []string
+	}{
+		{"without diversity", 0, []string{"Rust async runtime", "Rust async runtime internals"}},
+		// 第二篇与已选文章话题完全重合，扣分后低于第三篇
+		{"with diversity", 1, []string{"Rust async runtime", "Postgres indexes"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selector := newTestSelector(&config.SelectionWeights{Score: 1, Diversity: tt.diversity}, 2, nil)
+			got := selectedTitles(selector.Select(context.Background(), "hn", stories, selectorNow))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Select() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectNoveltyDecay(t *testing.T) {
+	stories := []models.Story{
+		{ID: 1, Title: "Rust async runtime internals", Score: 300, Time: selectorNow},
+		{ID: 2, Title: "Postgres indexes", Score: 100, Time: selectorNow},
+	}
+	tests := []struct {
+		name    string
+		age     time.Duration
+		history models.Story
+		want    string
+	}{
+		// 相似度按精选时间衰减：1 天前权重 6/7，新颖度低于第二篇
+		{"similar topic yesterday", 24 * time.Hour, models.Story{ID: 10, Title: "Rust async runtime"}, "Postgres indexes"},
+		// 6.5 天前权重 1/14，基础得分更高的第一篇仍然入选
+		{"similar topic last week", 156 * time.Hour, models.Story{ID: 10, Title: "Rust async runtime"}, "Rust async runtime internals"},
+		// 超过 novelty_days 的精选不参与比较
+		{"outside window", 8 * 24 * time.Hour, models.Story{ID: 10, Title: "Rust async runtime"}, "Rust async runtime internals"},
+		// novelty_days 内已收录的文章新颖度为 0
+		{"same story", 156 * time.Hour, models.Story{ID: 1, Title: "Unrelated"}, "Postgres indexes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeSelectionStore{histories: []models.SelectionHistory{
+				{Source: "hn", Pid: "hn-1", Stories: []models.Story{tt.history}, CreatedAt: selectorNow.Add(-tt.age)},
+				// 其他来源的精选不影响排序
+				{Source: "dev", Pid: "dev-1", Stories: []models.Story{{ID: 2, Title: "Postgres indexes"}}, CreatedAt: selectorNow},
+			}}
+			selector := newTestSelector(&config.SelectionWeights{Score: 1, Novelty: 1}, 1, store)
+			got := selectedTitles(selector.Select(context.Background(), "hn", stories, selectorNow))
+			if len(got) != 1 || got[0] != tt.want {
+				t.Errorf("Select() = %v, want [%s]", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStoryTopics(t *testing.T) {
+	tests := []struct {
+		name  string
+		story models.Story
+		want  map[string]bool
+	}{
+		{"title tags and host", models.Story{Title: "The Rust async book", URL: "https://www.rust-lang.org/learn", Tags: []string{"Rust"}},
+			map[string]bool{"rust": true, "async": true, "book": true, "tag:rust": true, "host:rust-lang.org": true}},
+		// 文本帖没有链接，不生成空域名
+		{"no url", models.Story{Title: "Ask HN: Rust?"}, map[string]bool{"rust": true}},
+		{"relative url", models.Story{Title: "Rust", URL: "/item?id=1"}, map[string]bool{"rust": true}},
+		// 代码托管网站上的不同项目不算同一话题
+		{"code host", models.Story{Title: "Rust", URL: "https://github.com/rust-lang/rust"}, map[string]bool{"rust": true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := storyTopics(tt.story); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("storyTopics() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectorRecord(t *testing.T) {
+	digest := &models.Digest{
+		Source:    "hn",
+		Pid:       "hn-1",
+		Content:   "正文",
+		Stories:   []models.Story{{ID: 1, Title: "Rust", URL: "https://a.example", Tags: []string{"rust"}, Content: "原文", Summary: "总结"}},
+		CreatedAt: selectorNow,
+	}
+
+	store := &fakeSelectionStore{}
+	newTestSelector(&config.SelectionWeights{Score: 1}, 1, store).Record(context.Background(), digest)
+	if len(store.saved) != 0 {
+		t.Fatalf("未配置新颖度时保存了 %d 条历史", len(store.saved))
+	}
+
+	newTestSelector(&config.SelectionWeights{Score: 1, Novelty: 1}, 1, store).Record(context.Background(), digest)
+	if len(store.saved) != 1 {
+		t.Fatalf("保存了 %d 条历史, want 1", len(store.saved))
+	}
+	want := []models.Story{{ID: 1, Title: "Rust", URL: "https://a.example", Tags: []string{"rust"}}}
+	if got := store.saved[0]; got.Pid != "hn-1" || !got.CreatedAt.Equal(selectorNow) || !reflect.DeepEqual(got.Stories, want) {
+		t.Errorf("历史 = %+v, want 只包含计算话题所需的字段", got)
+	}
+}
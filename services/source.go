@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/hacker-news-ai/config"
+	"github.com/hacker-news-ai/models"
+)
+
+// Source 文章来源
+type Source interface {
+	// Name 来源标识，用于配置和发布目标
+	Name() string
+	// FetchTopStories 获取热门文章及其原文和评论内容
+	FetchTopStories(ctx context.Context) ([]models.Story, error)
+}
+
+// StoryCommitter 需要在发布成功后确认文章的来源，例如 RSS 在发布后才将条目标记为已读
+type StoryCommitter interface {
+	// Commit 确认本次运行已发布的文章，只在至少一个发布目标成功后调用
+	Commit(ctx context.Context, stories []models.Story) error
+}
+
+// NewSource 根据来源标识创建文章来源
+func NewSource(cfg *config.Config, name string) (Source, error) {
+	switch name {
+	case "hn":
+		return NewHNService(cfg), nil
+	case "hn_best", "hn_new", "hn_ask", "hn_show", "hn_job":
+		return NewHNListService(cfg, strings.TrimPrefix(name, "hn_")), nil
+	case "dev":
+		return NewDevService(cfg), nil
+	case "lobsters":
+		return NewLobstersService(cfg), nil
+	case "reddit":
+		return NewRedditService(cfg), nil
+	case "github":
+		return NewGitHubService(cfg), nil
+	case "rss":
+		return NewRSSService(cfg), nil
+	default:
+		return nil, fmt.Errorf("未知的文章来源: %s", name)
+	}
+}
+
+// DigestSpec 每个来源的精选标题、介绍和文章信息格式
+type DigestSpec struct {
+	// 日志中显示的来源名称
+	Name string
+	// 精选 Pid 前缀
+	PidPrefix string
+	// 标题格式，%s 为日期
+	TitleFormat string
+	// 正文标题，同时作为封面图片的说明
+	Heading string
+	// 正文开头的介绍
+	Intro string
+	// 封面图片，为空时不显示
+	Cover string
+	// 讨论页名称，为空时不显示讨论页链接
+	DiscussionLabel string
+	// 评分名称，为空时不显示评分和评论数
+	ScoreLabel string
+	// 自定义单篇文章格式，为空时使用默认格式
+	StoryTemplate *template.Template
+}
+
+// ParseStoryTemplate 解析来源配置中的文章格式模板
+func ParseStoryTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("story").Funcs(template.FuncMap{"join": strings.Join}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("解析文章模板失败: %v", err)
+	}
+	return tmpl, nil
+}
+
+// DigestSpecs 各来源的精选格式
+var DigestSpecs = map[string]DigestSpec{
+	"hn": {
+		Name:            "Hacker News",
+		PidPrefix:       "HN",
+		TitleFormat:     "每日科技新知 NO.%s：Hacker News 中文解读，科技前沿热点速递",
+		Heading:         "Hacker News 中文精选",
+		Intro:           "一个基于 Hacker News 的中文日报项目，每天自动抓取 Hacker News 热门文章及评论，通过 AI 生成中文解读与总结，传递科技前沿信息。",
+		Cover:           "https://cdn.wangtwothree.com/imgur/f6uVgbS.jpeg",
+		DiscussionLabel: "Hacker News",
+		ScoreLabel:      "评分",
+	},
+	"hn_best": {
+		Name:            "Hacker News Best",
+		PidPrefix:       "HNB",
+		TitleFormat:     "HN 高分精选 NO.%s：近期最受好评的 Hacker News 文章中文解读",
+		Heading:         "Hacker News 高分精选",
+		Intro:           "本期收录 Hacker News 近期得分最高的文章及评论，通过 AI 生成中文解读与总结，帮你补上错过的高质量讨论。",
+		Cover:           "https://cdn.wangtwothree.com/imgur/f6uVgbS.jpeg",
+		DiscussionLabel: "Hacker News",
+		ScoreLabel:      "评分",
+	},
+	"hn_new": {
+		Name:            "Hacker News New",
+		PidPrefix:       "HNN",
+		TitleFormat:     "HN 新鲜速递 NO.%s：Hacker News 最新提交中文解读",
+		Heading:         "Hacker News 最新提交",
+		Intro:           "本期收录 Hacker News 最新提交的文章，通过 AI 生成中文解读与总结，第一时间了解社区正在分享什么。",
+		Cover:           "https://cdn.wangtwothree.com/imgur/f6uVgbS.jpeg",
+		DiscussionLabel: "Hacker News",
+		ScoreLabel:      "评分",
+	},
+	"hn_ask": {
+		Name:            "Ask HN",
+		PidPrefix:       "ASK",
+		TitleFormat:     "Ask HN 精选 NO.%s：Hacker News 社区问答中文解读",
+		Heading:         "Ask HN 中文精选",
+		Intro:           "Ask HN 是 Hacker News 上的提问版块，开发者和创业者在这里分享困惑、经验与建议。本期通过 AI 整理热门问题及高赞回答的中文解读。",
+		Cover:           "https://cdn.wangtwothree.com/imgur/f6uVgbS.jpeg",
+		DiscussionLabel: "Hacker News",
+		ScoreLabel:      "评分",
+	},
+	"hn_show": {
+		Name:            "Show HN",
+		PidPrefix:       "SHOW",
+		TitleFormat:     "Show HN 精选 NO.%s：Hacker News 社区作品展示中文解读",
+		Heading:         "Show HN 中文精选",
+		Intro:           "Show HN 是 Hacker News 上展示个人作品的版块，开发者在这里发布自己做的项目并听取社区反馈。本期通过 AI 整理热门作品及评论的中文解读。",
+		Cover:           "https://cdn.wangtwothree.com/imgur/f6uVgbS.jpeg",
+		DiscussionLabel: "Hacker News",
+		ScoreLabel:      "评分",
+	},
+	"hn_job": {
+		Name:            "HN Jobs",
+		PidPrefix:       "JOB",
+		TitleFormat:     "HN 招聘速览 NO.%s：YC 创业公司职位中文解读",
+		Heading:         "Hacker News 招聘精选",
+		Intro:           "本期收录 Hacker News 上 YC 创业公司发布的招聘信息，通过 AI 整理公司、职位与要求的中文介绍。",
+		Cover:           "https://cdn.wangtwothree.com/imgur/f6uVgbS.jpeg",
+		DiscussionLabel: "Hacker News",
+	},
+	"dev": {
+		Name:        "dev.to",
+		PidPrefix:   "DEV",
+		TitleFormat: "开发者简报 NO.%s：DEV 社区中文解读，全球开发者技术瞭望",
+		Heading:     "DEV 社区中文精选",
+		Intro:       "Dev Community 是一个面向全球开发者的技术博客与协作平台，本文是基于 dev.to 的中文日报项目，每天自动抓取 Dev Community 热门文章及评论，通过 AI 生成中文解读与总结，传递科技前沿信息。",
+		Cover:       "https://cdn.wangtwothree.com/imgur/ebLSg8b.png",
+		ScoreLabel:  "点赞数",
+	},
+	"lobsters": {
+		Name:            "Lobsters",
+		PidPrefix:       "LOB",
+		TitleFormat:     "龙虾日报 NO.%s：Lobsters 中文解读，开发者社区深度讨论",
+		Heading:         "Lobsters 中文精选",
+		Intro:           "Lobsters 是一个以计算机技术为主题、采用邀请制的链接聚合社区，讨论质量以深度著称。本文是基于 Lobsters 的中文日报项目，每天自动抓取 Lobsters 热门文章及评论，通过 AI 生成中文解读与总结。",
+		DiscussionLabel: "Lobsters",
+		ScoreLabel:      "评分",
+	},
+	"reddit": {
+		Name:            "Reddit",
+		PidPrefix:       "RDT",
+		TitleFormat:     "Reddit 编程周边 NO.%s：热门技术讨论中文解读",
+		Heading:         "Reddit 编程社区中文精选",
+		Intro:           "本文是基于 Reddit 编程类社区的中文日报项目，每天自动抓取 r/programming 等技术版块的热门帖子及评论，通过 AI 生成中文解读与总结，带你了解海外开发者正在讨论什么。",
+		DiscussionLabel: "Reddit",
+		ScoreLabel:      "得票",
+	},
+	"github": {
+		Name:        "GitHub Trending",
+		PidPrefix:   "GH",
+		TitleFormat: "GitHub 热门项目 NO.%s：开源趋势中文解读",
+		Heading:     "GitHub 趋势项目精选",
+		Intro:       "本文是基于 GitHub Trending 的中文日报项目，每天自动收集 Star 增长最快的开源仓库，阅读项目 README 与元数据，通过 AI 用中文介绍项目是什么、解决什么问题以及为什么值得关注。",
+		ScoreLabel:  "Star",
+	},
+	"rss": {
+		Name:        "技术博客",
+		PidPrefix:   "BLOG",
+		TitleFormat: "技术博客精选 NO.%s：工程团队与独立博客中文导读",
+		Heading:     "技术博客中文精选",
+		Intro:       "本文汇集了我们关注的工程团队博客与独立技术博客的最新文章，自动抓取全文并通过 AI 生成中文解读，帮你快速了解业内一线的技术实践。",
+	},
+}
+
+// FormatStory 生成单篇文章在精选中的内容
+func (spec DigestSpec) FormatStory(story models.Story) string {
+	var b strings.Builder
+	if spec.StoryTemplate != nil {
+		err := spec.StoryTemplate.Execute(&b, story)
+		if err == nil {
+			return b.String()
+		}
+		slog.Warn("渲染文章模板失败，使用默认格式", LogStoryID, story.ID, "title", story.Title, "error", err)
+		b.Reset()
+	}
+	if story.Kind == models.KindRepo {
+		fmt.Fprintf(&b, "%s\n\n- 仓库: [%s](%s)\n", story.Summary, story.Title, story.URL)
+		if gained := story.Meta["stars_gained"]; gained != "" {
+			fmt.Fprintf(&b, "- %s: %d（%s）\n", spec.ScoreLabel, story.Score, gained)
+		} else {
+			fmt.Fprintf(&b, "- %s: %d\n", spec.ScoreLabel, story.Score)
+		}
+		fmt.Fprintf(&b, "- 语言: %s\n", story.Meta["language"])
+		fmt.Fprintf(&b, "- 许可证: %s\n", story.Meta["license"])
+		if len(story.Tags) > 0 {
+			fmt.Fprintf(&b, "- 话题: %s\n", strings.Join(story.Tags, ", "))
+		}
+		fmt.Fprintf(&b, "- 创建时间: %s\n\n---\n\n", story.Time.Format("2006-01-02"))
+		return b.String()
+	}
+
+	if story.Kind == models.KindJob {
+		fmt.Fprintf(&b, "%s\n\n- 职位: [%s](%s)\n", story.Summary, story.Title, story.URL)
+		fmt.Fprintf(&b, "- 发布者: %s\n", story.By)
+		fmt.Fprintf(&b, "- 发布时间: %s\n\n---\n\n", story.Time.Format("2006-01-02 15:04:05"))
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "%s\n\n- 原文: [%s](%s)\n", story.Summary, story.Title, story.URL)
+	if spec.DiscussionLabel != "" && story.DiscussionURL != "" {
+		fmt.Fprintf(&b, "- %s: [%s](%s)\n", spec.DiscussionLabel, story.DiscussionURL, story.DiscussionURL)
+	}
+	fmt.Fprintf(&b, "- 作者: %s\n", story.By)
+	// 没有评分和评论的来源（如博客订阅）不显示这两项
+	if spec.ScoreLabel != "" {
+		fmt.Fprintf(&b, "- %s: %d\n", spec.ScoreLabel, story.Score)
+		fmt.Fprintf(&b, "- 评论数: %d\n", story.Descendants)
+	}
+	if len(story.Tags) > 0 {
+		fmt.Fprintf(&b, "- 标签: %s\n", strings.Join(story.Tags, ", "))
+	}
+	fmt.Fprintf(&b, "- 发布时间: %s\n\n---\n\n", story.Time.Format("2006-01-02 15:04:05"))
+	return b.String()
+}
+
+// Pid 返回 now 当天精选的 Pid，每个来源每天一期
+func (spec DigestSpec) Pid(now time.Time) string {
+	return spec.PidPrefix + now.Format("20060102")
+}
+
+// BuildDigest 将已生成总结的文章组合为一期精选
+func (spec DigestSpec) BuildDigest(source string, stories []models.Story, now time.Time) *models.Digest {
+	var body strings.Builder
+	for _, story := range stories {
+		body.WriteString(spec.FormatStory(story))
+	}
+
+	today := now.Format("20060102")
+	header := fmt.Sprintf("## %s NO.%s\n\n%s\n\n", spec.Heading, today, spec.Intro)
+	if spec.Cover != "" {
+		header += fmt.Sprintf("![%s](%s)\n", spec.Heading, spec.Cover)
+	}
+	// 原文只用于生成总结和渲染文章，不随精选保存到 ai_digest，也不出现在订阅和 Webhook 内容中
+	digestStories := make([]models.Story, len(stories))
+	for i, story := range stories {
+		story.Content = ""
+		digestStories[i] = story
+	}
+	return &models.Digest{
+		Source:    source,
+		Pid:       spec.Pid(now),
+		Title:     fmt.Sprintf(spec.TitleFormat, today),
+		Content:   header + "---\n\n" + body.String(),
+		Cover:     spec.Cover,
+		Stories:   digestStories,
+		CreatedAt: now,
+	}
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hacker-news-ai/config"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// TracerName 本程序创建 span 使用的 tracer 名称
+const TracerName = "github.com/hacker-news-ai"
+
+// NewTracerProvider 按配置创建链路追踪，返回的函数用于退出前导出剩余的 span
+// 未设置 tracing.endpoint 时返回不记录的 TracerProvider
+func NewTracerProvider(cfg config.TracingConfig) (trace.TracerProvider, func(context.Context) error, error) {
+	if cfg.Endpoint == "" {
+		return noop.NewTracerProvider(), func(context.Context) error { return nil }, nil
+	}
+
+	options := []otlptracehttp.Option{otlptracehttp.WithEndpointURL(strings.TrimSuffix(cfg.Endpoint, "/") + "/v1/traces")}
+	if len(cfg.Headers) > 0 {
+		options = append(options, otlptracehttp.WithHeaders(cfg.Headers))
+	}
+	// 创建导出器不会连接接收端，导出失败时由 SDK 记录错误，不影响运行
+	exporter, err := otlptracehttp.New(context.Background(), options...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("创建链路导出器失败: %v", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(cfg.ServiceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	return provider, provider.Shutdown, nil
+}
+
+// StartSpan 在 ctx 所在的链路下创建子 span，ctx 中没有链路时不记录
+// 各服务不持有 TracerProvider，链路由应用在每次运行开始时创建
+func StartSpan(ctx context.Context, name string, options ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return trace.SpanFromContext(ctx).TracerProvider().Tracer(TracerName).Start(ctx, name, options...)
+}
+
+// EndSpan 结束 span，err 不为空时标记为失败
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
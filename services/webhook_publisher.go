@@ -0,0 +1,61 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/hacker-news-ai/config"
+	"github.com/hacker-news-ai/models"
+)
+
+// WebhookPublisher 以 JSON 形式将精选内容推送到自定义 Webhook
+type WebhookPublisher struct {
+	config config.WebhookConfig
+	client *http.Client
+}
+
+func NewWebhookPublisher(cfg config.WebhookConfig) *WebhookPublisher {
+	return &WebhookPublisher{
+		config: cfg,
+		client: newHTTPClient(30 * time.Second),
+	}
+}
+
+func (p *WebhookPublisher) Name() string {
+	return "webhook"
+}
+
+// Publish 推送精选内容到 Webhook
+func (p *WebhookPublisher) Publish(ctx context.Context, digest *models.Digest) error {
+	body, err := json.Marshal(digest)
+	if err != nil {
+		return fmt.Errorf("序列化精选内容失败: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建Webhook请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range p.config.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("推送Webhook失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("推送Webhook失败: %s %s", resp.Status, string(respBody))
+	}
+
+	return nil
+}